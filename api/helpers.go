@@ -22,3 +22,67 @@ func ConvertTime(h string) (time.Time, error) {
 		return time.Parse("15:04", h)
 	}
 }
+
+// CanWrite checks if the key can be used to modify data
+func (k APIKey) CanWrite() bool {
+	return k.Permission == PermissionWrite
+}
+
+// IsScopedTo checks if the key gives access to the team
+func (k APIKey) IsScopedTo(teamID string) bool {
+	return k.TeamID == AllTeams || k.TeamID == teamID
+}
+
+// IsValidMeetingType checks if the type is one of the supported meetings
+func IsValidMeetingType(meetingType string) bool {
+	_, ok := MeetingNames[meetingType]
+	return ok
+}
+
+// GetQuestions returns the questions of the meeting, the default ones of its type if it hasn't any
+func (m Meeting) GetQuestions() []string {
+	if len(m.Questions) > 0 {
+		return m.Questions
+	}
+	return DefaultQuestions[m.Type]
+}
+
+// GetName returns the name of the meeting, the one of its type if it hasn't any
+func (m Meeting) GetName() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return MeetingNames[m.Type]
+}
+
+// IsValidOrder checks if the speaking order is one of the available ones
+func IsValidOrder(order string) bool {
+	switch order {
+	case OrderDefault, OrderRandom, OrderRotation, OrderAlphabetical, OrderManual:
+		return true
+	}
+	return false
+}
+
+// IsAdmin checks if the member can change the configuration of the Daily Meeting
+func (m Member) IsAdmin() bool {
+	return m.Role == RoleAdmin
+}
+
+// IsObserver checks if the member only follows the Daily Meeting, without being asked
+func (m Member) IsObserver() bool {
+	return m.Role == RoleObserver
+}
+
+// Location returns the member's timezone, or the local one if it's unknown
+func (m Member) Location() *time.Location {
+	if m.TimeZone == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(m.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
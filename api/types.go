@@ -1,16 +1,31 @@
 // Package api contains the types used and exposed by the API Server
 package api
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
-// Member represents a member of the team
+// Member represents a member of the team, ID is the user ID in the chat (e.g. U123 in Slack)
 type Member struct {
 	ID        string `json:"id"`
 	Name      string `json:"name"`
+	RealName  string `json:"realName"`
+	TimeZone  string `json:"timeZone"`
+	Avatar    string `json:"avatar"`
+	Role      string `json:"role"`
 	ChannelID string `json:"channelId"`
 	TeamID    string `json:"teamId"`
 }
 
+// Roles of the members: admins configure the Daily Meeting and observers don't take part in it. Members
+// without role are considered RoleMember
+const (
+	RoleAdmin    = "admin"
+	RoleMember   = "member"
+	RoleObserver = "observer"
+)
+
 // Channel represents a channel or group where members chat
 type Channel struct {
 	ID     string `json:"id"`
@@ -25,15 +40,367 @@ type Team struct {
 	Token string `json:"slackToken"`
 }
 
-// DailyMeeting represents a Daily Meeting with its status
+// APIKey authenticates the clients of the API Server, it gives read or write access to a team
+type APIKey struct {
+	Key        string `json:"key"`
+	TeamID     string `json:"teamId"`
+	Permission string `json:"permission"`
+}
+
+// Permissions of the API keys, AllTeams is only used by the master key
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+	AllTeams        = "*"
+)
+
+// DailyMeeting represents a Daily Meeting with its status, timeouts are in seconds (0 means default). Schedule is
+// a cron expression or a recurrence rule, used instead of Days and StartTime when it's set. With LimitTime, the
+// meeting starts between StartTime and LimitTime when ActiveShare percent of the members are online. With Mood,
+// each member rates how they feel before answering, LastMoodReport is when the weekly chart was posted
 type DailyMeeting struct {
-	ChannelID string         `json:"channelId"`
-	LastDaily time.Time      `json:"lastDaily"`
-	StartTime time.Time      `json:"startTime"`
-	LimitTime time.Time      `json:"limitTime"`
-	Days      []time.Weekday `json:"days"`
+	ChannelID           string         `json:"channelId"`
+	LastDaily           time.Time      `json:"lastDaily"`
+	StartTime           time.Time      `json:"startTime"`
+	LimitTime           time.Time      `json:"limitTime"`
+	Days                []time.Weekday `json:"days"`
+	Schedule            string         `json:"schedule"`
+	ActiveShare         int            `json:"activeShare"`
+	Threaded            bool           `json:"threaded"`
+	ReadyTimeout        int            `json:"readyTimeout"`
+	AnswerTimeout       int            `json:"answerTimeout"`
+	Order               string         `json:"order"`
+	ManualOrder         []string       `json:"manualOrder"`
+	Rotation            int            `json:"rotation"`
+	Facilitator         string         `json:"facilitator"`
+	FacilitatorRotation int            `json:"facilitatorRotation"`
+	Mood                bool           `json:"mood"`
+	LastMoodReport      time.Time      `json:"lastMoodReport"`
+	TimesheetSchedule   string         `json:"timesheetSchedule"`
+	ExpectedHours       float64        `json:"expectedHours"`
+	LastTimesheet       time.Time      `json:"lastTimesheet"`
+	MorningSchedule     string         `json:"morningSchedule"`
+	LastMorning         time.Time      `json:"lastMorning"`
+	Content             string         `json:"content"`
+	Language            string         `json:"language"`
+	BotName             string         `json:"botName"`
+	BotIcon             string         `json:"botIcon"`
+}
+
+// NextDaily is the next occurrence of a Daily Meeting, Schedule is empty when it's scheduled by days
+type NextDaily struct {
+	ChannelID string    `json:"channelId"`
+	Schedule  string    `json:"schedule"`
+	Next      time.Time `json:"next"`
+}
+
+// DailyProgress is the state of a Daily Meeting in progress, saved after every step to resume it after a restart.
+// Members are in speaking order and Question is the number of questions answered by the Current member
+type DailyProgress struct {
+	ChannelID    string        `json:"channelId"`
+	Started      time.Time     `json:"started"`
+	Updated      time.Time     `json:"updated"`
+	ThreadTS     string        `json:"threadTs"`
+	Facilitator  string        `json:"facilitator"`
+	Members      []string      `json:"members"`
+	Done         []string      `json:"done"`
+	Skipped      []string      `json:"skipped"`
+	Current      string        `json:"current"`
+	Question     int           `json:"question"`
+	Answers      []DailyAnswer `json:"answers"`
+	SnoozedUntil time.Time     `json:"snoozedUntil"`
+}
+
+// DailyAnswer is the answer of a member to a question of the Daily Meeting, Question is its index. Late answers
+// were given with `daily resume` after the member's turn
+type DailyAnswer struct {
+	MemberID string    `json:"memberId"`
+	Question int       `json:"question"`
+	Text     string    `json:"text"`
+	Date     time.Time `json:"date"`
+	Late     bool      `json:"late"`
+}
+
+// DailyDigest gathers all the answers to the Daily Meeting of a channel in a day, Members are the ones who had to
+// report
+type DailyDigest struct {
+	ChannelID string        `json:"channelId"`
+	Date      time.Time     `json:"date"`
+	ThreadTS  string        `json:"threadTs"`
+	Members   []string      `json:"members"`
+	Answers   []DailyAnswer `json:"answers"`
+}
+
+// HasMember returns true if the member had to report in the Daily Meeting
+func (d DailyDigest) HasMember(memberID string) bool {
+	for _, m := range d.Members {
+		if m == memberID {
+			return true
+		}
+	}
+	return false
+}
+
+// Answered returns how many questions the member has answered, in order
+func (d DailyDigest) Answered(memberID string) int {
+	answered := map[int]bool{}
+	for _, a := range d.Answers {
+		if a.MemberID == memberID {
+			answered[a.Question] = true
+		}
+	}
+
+	n := 0
+	for answered[n] {
+		n++
+	}
+	return n
+}
+
+// Pending returns the members who haven't answered all the questions given
+func (d DailyDigest) Pending(questions int) []string {
+	pending := []string{}
+	for _, m := range d.Members {
+		if d.Answered(m) < questions {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Meeting is a recurring meeting of a channel besides the Daily Meeting, like a retrospective or a planning. Its
+// participants are member IDs, all the members of the channel when it's empty. Retrospectives can hide who wrote
+// each entry and keep their board open to votes VotingTimeout seconds
+type Meeting struct {
+	ID            string    `json:"id"`
+	ChannelID     string    `json:"channelId"`
+	Type          string    `json:"type"`
+	Name          string    `json:"name"`
+	Schedule      string    `json:"schedule"`
+	Questions     []string  `json:"questions"`
+	Participants  []string  `json:"participants"`
+	LastRun       time.Time `json:"lastRun"`
+	Anonymous     bool      `json:"anonymous"`
+	VotingTimeout int       `json:"votingTimeout"`
+}
+
+// Types of meetings
+const (
+	MeetingDaily         = "daily"
+	MeetingRetrospective = "retrospective"
+	MeetingPlanning      = "planning"
+	MeetingCheckIn       = "checkin"
+)
+
+// MeetingNames are the default names of the meetings by type
+var MeetingNames = map[string]string{
+	MeetingDaily:         "Daily Meeting",
+	MeetingRetrospective: "Retrospective",
+	MeetingPlanning:      "Planning",
+	MeetingCheckIn:       "Check-in",
+}
+
+// ImpedimentsQuestion is the index of the question of the Daily Meeting about impediments
+const ImpedimentsQuestion = 2
+
+// DefaultQuestions are asked to each participant when the meeting hasn't its own questions
+var DefaultQuestions = map[string][]string{
+	MeetingDaily: {"what did you do yesterday?", "what will you do today?",
+		"are there any impediments in your way?"},
+	MeetingRetrospective: {"what went well?", "what should we improve?", "which actions should we take?"},
+	MeetingPlanning:      {"what do you want to work on next?", "how long will it take?", "do you depend on anyone?"},
+	MeetingCheckIn:       {"how are you doing today?"},
+}
+
+// MessageTemplate is the text/template of a bot message overridden in a channel, Default is true when the channel
+// hasn't overridden it
+type MessageTemplate struct {
+	ChannelID string `json:"channelId"`
+	Key       string `json:"key"`
+	Template  string `json:"template"`
+	Default   bool   `json:"default"`
+}
+
+// DefaultTemplates are the text/template of the bot messages which can be overridden per channel, by key. Every
+// message has the name of the bot as {{.Bot}}, the other fields are the ones used here
+var DefaultTemplates = map[string]string{
+	"hello": "Hello team! I'm here to help you with your daily meetings. To add members " +
+		"to the daily meeting type `@leanmanager daily add member`, to setup the hour of the " +
+		"daily meeting, type `@leanmanager daily schedule`.\n" +
+		"If you need help, just type `@leanmanager help` :sos:",
+	"help": "Even if I'm a bit surly, work with me it's quite easy :sunglasses:\n" +
+		"Just type the order, and I will obey. Those are the orders available:\n" +
+		"{{.Commands}}\n" +
+		"If I ask something, just reply, I will do my best to understand you :grin:",
+	"daily-start": "Hi @channel! Let's start the Daily Meeting :mega:{{if .Facilitator}}\n" +
+		"Today's facilitator is {{.Facilitator}} :crown: who can type " +
+		"`@leanmanager daily skip @member` or `@leanmanager daily end`{{end}}",
+	"daily-ready":    "Hi {{.Member}}! Are you ready?.",
+	"daily-question": "{{.Member}}, {{.Question}}",
+	"daily-thanks":   "Thanks {{.Member}}",
+	"daily-not-available": ":chicken:... please, do it later, just type `@leanmanager daily resume` before the " +
+		"end of the day",
+	"daily-answer-timeout": "{{.Member}} seems to be busy :hourglass: let's continue, just type " +
+		"`@leanmanager daily resume` to finish your Daily report later",
+	"daily-back": "Hi @channel! We are back, let's go on with the Daily Meeting :alarm_clock:",
+	"daily-resume-reminder": "{{.Member}}, your day is almost over and I'm still waiting for your Daily report " +
+		":alarm_clock: just type `@leanmanager daily resume`, or `daily resume` in a direct message",
+	"daily-done":        "Daily Meeting done :tada: Have a great day!{{if .Content}}\n{{.Content}}{{end}}",
+	"daily-stopped":     "Daily Meeting stopped :octagonal_sign: See you next time",
+	"daily-late-report": "Late Daily report of {{.Member}} :memo:",
+	"no-members": "There are no members registered yet. Type `@leanmanager daily add member` to add " +
+		"the first one",
+	"no-admin": ":no_entry: This channel has no admin, add one in `POST /members` of the API Server with the " +
+		"master key and `\"role\": \"admin\"`",
+	"unexpected-problem": "It was an unexpected behaviour, I don't have idea what's going to happen now... so " +
+		"you can wait and see what happens or contact support@leanmanager.eu asking for help",
+	"good-morning": "Good morning @channel! :sunny: This is the agenda for {{.Date}}:",
+	"reminder":     ":alarm_clock: {{if .Author}}Reminder from {{.Author}}: {{end}}{{.Text}}",
 }
 
+// RetroEntry is an answer given in a Retrospective, Author is empty when it's anonymous. Retro is the time the
+// Retrospective started, it groups the entries of each one
+type RetroEntry struct {
+	ID        int       `json:"id"`
+	ChannelID string    `json:"channelId"`
+	MeetingID string    `json:"meetingId"`
+	Retro     time.Time `json:"retro"`
+	Category  string    `json:"category"`
+	Text      string    `json:"text"`
+	Author    string    `json:"author"`
+	Votes     int       `json:"votes"`
+}
+
+// Categories of the Retrospective entries, in the order they are asked
+const (
+	RetroWentWell  = "went_well"
+	RetroToImprove = "to_improve"
+	RetroAction    = "action_item"
+)
+
+// RetroCategories are the categories of the Retrospective, each one is asked with a question of the meeting
+var RetroCategories = []string{RetroWentWell, RetroToImprove, RetroAction}
+
+// RetroCategoryNames are the titles of the categories in the Retrospective board
+var RetroCategoryNames = map[string]string{
+	RetroWentWell:  "Went well",
+	RetroToImprove: "To improve",
+	RetroAction:    "Action items",
+}
+
+// ActionItem is an action agreed in a Retrospective and followed in the Daily Meeting until it's done. Owner is
+// the member in charge, the whole team when it's empty
+type ActionItem struct {
+	ID        int       `json:"id"`
+	ChannelID string    `json:"channelId"`
+	MeetingID string    `json:"meetingId"`
+	Text      string    `json:"text"`
+	Owner     string    `json:"owner"`
+	Votes     int       `json:"votes"`
+	Done      bool      `json:"done"`
+	Created   time.Time `json:"created"`
+}
+
+// Reminder is a message the bot sends once At, or on every occurrence of Schedule, to TargetChannel or privately to
+// TargetMember. ChannelID is where it was created and Recipient the target as it was typed
+type Reminder struct {
+	ID            int       `json:"id"`
+	ChannelID     string    `json:"channelId"`
+	TargetChannel string    `json:"targetChannel"`
+	TargetMember  string    `json:"targetMember"`
+	Recipient     string    `json:"recipient"`
+	Text          string    `json:"text"`
+	Schedule      string    `json:"schedule"`
+	At            time.Time `json:"at"`
+	Author        string    `json:"author"`
+	LastRun       time.Time `json:"lastRun"`
+}
+
+// Absence is a period a member of the channel won't be working, From and To are its first and last days
+type Absence struct {
+	ID        int       `json:"id"`
+	ChannelID string    `json:"channelId"`
+	MemberID  string    `json:"memberId"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// Covers returns true if the member is absent the day given
+func (a Absence) Covers(day time.Time) bool {
+	d := day.Format("2006-01-02")
+	return a.From.Format("2006-01-02") <= d && d <= a.To.Format("2006-01-02")
+}
+
+// Mood is how a member feels a day, from MinMood to MaxMood. Date is the start of the day
+type Mood struct {
+	ChannelID string    `json:"channelId"`
+	MemberID  string    `json:"memberId"`
+	Date      time.Time `json:"date"`
+	Score     int       `json:"score"`
+}
+
+// Range of the mood scores, and the fewest answers averaged together so nobody's score can be told apart
+const (
+	MinMood        = 1
+	MaxMood        = 5
+	MinMoodAnswers = 3
+)
+
+// MoodTrend is the anonymized mood of the team until a day, the average of the scores of the members who answered.
+// Days with fewer than MinMoodAnswers answers are averaged with the next ones, or the previous ones at the end
+type MoodTrend struct {
+	Date    time.Time `json:"date"`
+	Average float64   `json:"average"`
+	Answers int       `json:"answers"`
+}
+
+// Timesheet is the hours a member spent in a week on each project or PR. Week is like 2017-W12
+type Timesheet struct {
+	ChannelID string           `json:"channelId"`
+	MemberID  string           `json:"memberId"`
+	Week      string           `json:"week"`
+	Entries   []TimesheetEntry `json:"entries"`
+	Submitted time.Time        `json:"submitted"`
+}
+
+// TimesheetEntry is the hours spent on a project or PR
+type TimesheetEntry struct {
+	Project string  `json:"project"`
+	Hours   float64 `json:"hours"`
+}
+
+// DefaultExpectedHours are the hours of a week in the timesheets if the channel doesn't set them
+const DefaultExpectedHours = 40
+
+// Total returns the hours of all the entries
+func (t Timesheet) Total() (total float64) {
+	for _, e := range t.Entries {
+		total += e.Hours
+	}
+	return total
+}
+
+// Week returns the ISO week of the day, like 2017-W12
+func Week(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// DailyOrder represents the speaking order of a Daily Meeting, ManualOrder contains member IDs
+type DailyOrder struct {
+	Order       string   `json:"order"`
+	ManualOrder []string `json:"manualOrder"`
+}
+
+// Speaking orders available for the Daily Meeting, members are asked in storage order by default
+const (
+	OrderDefault      = ""
+	OrderRandom       = "random"
+	OrderRotation     = "rotation"
+	OrderAlphabetical = "alphabetical"
+	OrderManual       = "manual"
+)
+
 // PredefinedDailyReply represents an automated reply to answers in the Daily Meeting following the exp criteria
 type PredefinedDailyReply struct {
 	ChannelID string `json:"channelId"`
@@ -2,19 +2,31 @@
 package apiserver
 
 import (
+	"encoding/csv"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/scheduler"
 	"github.com/antonmry/leanmanager/storage"
 	"github.com/emicklei/go-restful"
 	"github.com/emicklei/go-restful/swagger"
 )
 
-// DAO represents the access to the DB, it will be refactored to contain DB access info
+// weekPattern validates the ISO weeks of the timesheets, like 2017-W12
+var weekPattern = regexp.MustCompile(`^[0-9]{4}-W[0-9]{2}$`)
+
+// DAO represents the access to the DB, it will be refactored to contain DB access info. The master key gives
+// write access to all the teams
 type DAO struct {
+	masterKey string
 }
 
 func (dao DAO) register(container *restful.Container) {
@@ -25,7 +37,8 @@ func (dao DAO) register(container *restful.Container) {
 		Path("/dailymeetings").
 		Doc("Manage Daily Meetings").
 		Consumes(restful.MIME_JSON, restful.MIME_XML).
-		Produces(restful.MIME_JSON, restful.MIME_XML)
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
 
 	dailyWs.Route(dailyWs.POST("").To(dao.createDailyMeeting).
 		// docs
@@ -40,6 +53,27 @@ func (dao DAO) register(container *restful.Container) {
 		Param(dailyWs.PathParameter("bot-id", "identifier of the bot").DataType("string")).
 		Writes(api.DailyMeeting{}))
 
+	dailyWs.Route(dailyWs.GET("/{channel-id}/order").To(dao.findDailyOrder).
+		// docs
+		Doc("get the speaking order of a Daily Meeting").
+		Operation("findDailyOrder").
+		Param(dailyWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.DailyOrder{}))
+
+	dailyWs.Route(dailyWs.PUT("/{channel-id}/order").To(dao.updateDailyOrder).
+		// docs
+		Doc("set the speaking order of a Daily Meeting: random, rotation, alphabetical or manual").
+		Operation("updateDailyOrder").
+		Param(dailyWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Reads(api.DailyOrder{}))
+
+	dailyWs.Route(dailyWs.GET("/{channel-id}/next").To(dao.findNextDaily).
+		// docs
+		Doc("get when the Daily Meeting will run next time").
+		Operation("findNextDaily").
+		Param(dailyWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.NextDaily{}))
+
 	container.Add(dailyWs)
 
 	replyWs := new(restful.WebService)
@@ -48,7 +82,8 @@ func (dao DAO) register(container *restful.Container) {
 		Path("/replies").
 		Doc("Predefined replies to Daily Meetings questions").
 		Consumes(restful.MIME_JSON, restful.MIME_XML).
-		Produces(restful.MIME_JSON, restful.MIME_XML)
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
 
 	replyWs.Route(replyWs.POST("").To(dao.storePredefinedReply).
 		// docs
@@ -77,7 +112,8 @@ func (dao DAO) register(container *restful.Container) {
 		Path("/channels").
 		Doc("Manage Channels").
 		Consumes(restful.MIME_JSON, restful.MIME_XML).
-		Produces(restful.MIME_JSON, restful.MIME_XML)
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
 
 	channelWs.Route(channelWs.POST("").To(dao.createChannel).
 		// docs
@@ -93,7 +129,8 @@ func (dao DAO) register(container *restful.Container) {
 		Path("/members").
 		Doc("Manage Members").
 		Consumes(restful.MIME_JSON, restful.MIME_XML).
-		Produces(restful.MIME_JSON, restful.MIME_XML)
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
 
 	memberWs.Route(memberWs.GET("/{channel-id}/{member-id}").To(dao.findMember).
 		// docs
@@ -124,6 +161,355 @@ func (dao DAO) register(container *restful.Container) {
 		Param(memberWs.PathParameter("member-id", "identifier of the member").DataType("string")))
 
 	container.Add(memberWs)
+
+	progressWs := new(restful.WebService)
+
+	progressWs.
+		Path("/progress").
+		Doc("Persist the Daily Meetings in progress").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	progressWs.Route(progressWs.POST("").To(dao.storeDailyProgress).
+		// docs
+		Doc("save the state of the Daily Meeting in progress of a channel").
+		Operation("storeDailyProgress").
+		Reads(api.DailyProgress{}))
+
+	progressWs.Route(progressWs.GET("").To(dao.findDailyProgresses).
+		// docs
+		Doc("get the Daily Meetings in progress in the channels of the team").
+		Operation("findDailyProgresses").
+		Writes(api.DailyProgress{}))
+
+	progressWs.Route(progressWs.DELETE("/{channel-id}").To(dao.removeDailyProgress).
+		// docs
+		Doc("delete the Daily Meeting in progress of a channel").
+		Operation("removeDailyProgress").
+		Param(progressWs.PathParameter("channel-id", "ID of the Channel").DataType("string")))
+
+	container.Add(progressWs)
+
+	digestWs := new(restful.WebService)
+
+	digestWs.
+		Path("/digests").
+		Doc("Manage the answers to the Daily Meetings of each day").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	digestWs.Route(digestWs.POST("").To(dao.storeDailyDigest).
+		// docs
+		Doc("create or replace the digest of the Daily Meeting of a channel in a day").
+		Operation("storeDailyDigest").
+		Reads(api.DailyDigest{}))
+
+	digestWs.Route(digestWs.GET("/{channel-id}/{date}").To(dao.findDailyDigest).
+		// docs
+		Doc("get the digest of the Daily Meeting of a channel in a day").
+		Operation("findDailyDigest").
+		Param(digestWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(digestWs.PathParameter("date", "day of the Daily Meeting, like 2017-03-21").DataType("string")).
+		Writes(api.DailyDigest{}))
+
+	digestWs.Route(digestWs.POST("/{channel-id}/{date}/answers").To(dao.addDigestAnswers).
+		// docs
+		Doc("append answers to the digest of the Daily Meeting of a channel in a day").
+		Operation("addDigestAnswers").
+		Param(digestWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(digestWs.PathParameter("date", "day of the Daily Meeting, like 2017-03-21").DataType("string")).
+		Reads([]api.DailyAnswer{}))
+
+	container.Add(digestWs)
+
+	meetingWs := new(restful.WebService)
+
+	meetingWs.
+		Path("/meetings").
+		Doc("Manage recurring meetings").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	meetingWs.Route(meetingWs.POST("").To(dao.createMeeting).
+		// docs
+		Doc("create or update a meeting, an ID is given if it's empty").
+		Operation("createMeeting").
+		Reads(api.Meeting{}))
+
+	meetingWs.Route(meetingWs.GET("").To(dao.findMeetings).
+		// docs
+		Doc("get all the meetings in the channels of the team").
+		Operation("findMeetings").
+		Writes(api.Meeting{}))
+
+	meetingWs.Route(meetingWs.GET("/{channel-id}/").To(dao.findMeetingsByChannel).
+		// docs
+		Doc("get the meetings of a channel").
+		Operation("findMeetingsByChannel").
+		Param(meetingWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.Meeting{}))
+
+	meetingWs.Route(meetingWs.DELETE("/{channel-id}/{meeting-id}").To(dao.removeMeeting).
+		// docs
+		Doc("delete a meeting").
+		Operation("removeMeeting").
+		Param(meetingWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(meetingWs.PathParameter("meeting-id", "identifier of the meeting").DataType("string")))
+
+	container.Add(meetingWs)
+
+	retroWs := new(restful.WebService)
+
+	retroWs.
+		Path("/retros").
+		Doc("Manage the entries of the Retrospectives").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	retroWs.Route(retroWs.POST("").To(dao.createRetroEntry).
+		// docs
+		Doc("create or update an entry of a Retrospective, an ID is given if it's zero").
+		Operation("createRetroEntry").
+		Reads(api.RetroEntry{}))
+
+	retroWs.Route(retroWs.GET("/{channel-id}/").To(dao.findRetroEntriesByChannel).
+		// docs
+		Doc("get the entries of the Retrospectives of a channel").
+		Operation("findRetroEntriesByChannel").
+		Param(retroWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.RetroEntry{}))
+
+	container.Add(retroWs)
+
+	actionWs := new(restful.WebService)
+
+	actionWs.
+		Path("/actionitems").
+		Doc("Manage the action items agreed in the Retrospectives").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	actionWs.Route(actionWs.POST("").To(dao.createActionItem).
+		// docs
+		Doc("create or update an action item, an ID is given if it's zero").
+		Operation("createActionItem").
+		Reads(api.ActionItem{}))
+
+	actionWs.Route(actionWs.GET("/{channel-id}/").To(dao.findActionItemsByChannel).
+		// docs
+		Doc("get the action items of a channel, the done ones included").
+		Operation("findActionItemsByChannel").
+		Param(actionWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.ActionItem{}))
+
+	container.Add(actionWs)
+
+	reminderWs := new(restful.WebService)
+
+	reminderWs.
+		Path("/reminders").
+		Doc("Manage the reminders sent by the bot").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	reminderWs.Route(reminderWs.POST("").To(dao.createReminder).
+		// docs
+		Doc("create or update a reminder, an ID is given if it's zero").
+		Operation("createReminder").
+		Reads(api.Reminder{}))
+
+	reminderWs.Route(reminderWs.GET("").To(dao.findReminders).
+		// docs
+		Doc("get the reminders of all the channels of the team").
+		Operation("findReminders").
+		Writes(api.Reminder{}))
+
+	reminderWs.Route(reminderWs.GET("/{channel-id}/").To(dao.findRemindersByChannel).
+		// docs
+		Doc("get the reminders created in a channel").
+		Operation("findRemindersByChannel").
+		Param(reminderWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.Reminder{}))
+
+	reminderWs.Route(reminderWs.DELETE("/{channel-id}/{reminder-id}").To(dao.removeReminder).
+		// docs
+		Doc("delete a reminder").
+		Operation("removeReminder").
+		Param(reminderWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(reminderWs.PathParameter("reminder-id", "identifier of the reminder").DataType("integer")))
+
+	container.Add(reminderWs)
+
+	timesheetWs := new(restful.WebService)
+
+	timesheetWs.
+		Path("/timesheets").
+		Doc("Manage the weekly timesheets of the members").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	timesheetWs.Route(timesheetWs.POST("").To(dao.createTimesheet).
+		// docs
+		Doc("store the timesheet of a member in a week, replacing the previous one").
+		Operation("createTimesheet").
+		Reads(api.Timesheet{}))
+
+	timesheetWs.Route(timesheetWs.GET("/{channel-id}").To(dao.findTimesheets).
+		// docs
+		Doc("export the timesheets of a channel in a week as CSV, or as JSON if it's accepted").
+		Operation("findTimesheets").
+		Produces("text/csv", restful.MIME_JSON).
+		Param(timesheetWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(timesheetWs.QueryParameter("week", "ISO week like 2017-W12, the current one by default").
+			DataType("string")).
+		Writes(api.Timesheet{}))
+
+	container.Add(timesheetWs)
+
+	absenceWs := new(restful.WebService)
+
+	absenceWs.
+		Path("/absences").
+		Doc("Manage the absences of the members").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	absenceWs.Route(absenceWs.POST("").To(dao.createAbsence).
+		// docs
+		Doc("create or update an absence, an ID is given if it's zero").
+		Operation("createAbsence").
+		Reads(api.Absence{}))
+
+	absenceWs.Route(absenceWs.GET("/{channel-id}/").To(dao.findAbsencesByChannel).
+		// docs
+		Doc("get the absences of the members of a channel").
+		Operation("findAbsencesByChannel").
+		Param(absenceWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.Absence{}))
+
+	absenceWs.Route(absenceWs.DELETE("/{channel-id}/{absence-id}").To(dao.removeAbsence).
+		// docs
+		Doc("delete an absence").
+		Operation("removeAbsence").
+		Param(absenceWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(absenceWs.PathParameter("absence-id", "identifier of the absence").DataType("integer")))
+
+	container.Add(absenceWs)
+
+	templateWs := new(restful.WebService)
+
+	templateWs.
+		Path("/templates").
+		Doc("Override the templates of the bot messages in a channel").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	templateWs.Route(templateWs.GET("/{channel-id}/").To(dao.findTemplatesByChannel).
+		// docs
+		Doc("get the templates of all the messages in a channel, the overridden and the default ones").
+		Operation("findTemplatesByChannel").
+		Param(templateWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Writes(api.MessageTemplate{}))
+
+	templateWs.Route(templateWs.GET("/{channel-id}/{message-key}").To(dao.findTemplate).
+		// docs
+		Doc("get the template of a message in a channel").
+		Operation("findTemplate").
+		Param(templateWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(templateWs.PathParameter("message-key", "key of the message").DataType("string")).
+		Writes(api.MessageTemplate{}))
+
+	templateWs.Route(templateWs.PUT("/{channel-id}/{message-key}").To(dao.updateTemplate).
+		// docs
+		Doc("override the template of a message in a channel").
+		Operation("updateTemplate").
+		Param(templateWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(templateWs.PathParameter("message-key", "key of the message").DataType("string")).
+		Reads(api.MessageTemplate{}))
+
+	templateWs.Route(templateWs.DELETE("/{channel-id}/{message-key}").To(dao.removeTemplate).
+		// docs
+		Doc("go back to the default template of a message in a channel").
+		Operation("removeTemplate").
+		Param(templateWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(templateWs.PathParameter("message-key", "key of the message").DataType("string")))
+
+	container.Add(templateWs)
+
+	moodWs := new(restful.WebService)
+
+	moodWs.
+		Path("/moods").
+		Doc("Manage the moods of the members").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	moodWs.Route(moodWs.POST("").To(dao.createMood).
+		// docs
+		Doc("store the mood of a member, one per day").
+		Operation("createMood").
+		Reads(api.Mood{}))
+
+	container.Add(moodWs)
+
+	reportWs := new(restful.WebService)
+
+	reportWs.
+		Path("/reports").
+		Doc("Reports of the teams").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	reportWs.Route(reportWs.GET("/{channel-id}/mood").To(dao.findMoodTrend).
+		// docs
+		Doc("get the average mood of the team by day, without the scores of each member").
+		Operation("findMoodTrend").
+		Param(reportWs.PathParameter("channel-id", "ID of the Channel").DataType("string")).
+		Param(reportWs.QueryParameter("days", "number of days, 30 by default").DataType("integer")).
+		Writes(api.MoodTrend{}))
+
+	container.Add(reportWs)
+
+	keyWs := new(restful.WebService)
+
+	keyWs.
+		Path("/apikeys").
+		Doc("Manage API keys, only with the master key").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(dao.authenticate)
+
+	keyWs.Route(keyWs.POST("").To(dao.createAPIKey).
+		// docs
+		Doc("create an API key for a team, the key is generated").
+		Operation("createAPIKey").
+		Reads(api.APIKey{}))
+
+	keyWs.Route(keyWs.GET("/{team-id}/").To(dao.findAPIKeysByTeam).
+		// docs
+		Doc("get all the API keys of a team").
+		Operation("findAPIKeysByTeam").
+		Param(keyWs.PathParameter("team-id", "identifier of the team").DataType("string")).
+		Writes(api.APIKey{}))
+
+	keyWs.Route(keyWs.DELETE("/{key}").To(dao.removeAPIKey).
+		// docs
+		Doc("revoke an API key").
+		Operation("removeAPIKey").
+		Param(keyWs.PathParameter("key", "the API key").DataType("string")))
+
+	container.Add(keyWs)
 }
 
 func (dao *DAO) createDailyMeeting(request *restful.Request, response *restful.Response) {
@@ -135,6 +521,19 @@ func (dao *DAO) createDailyMeeting(request *restful.Request, response *restful.R
 		response.WriteErrorString(http.StatusInternalServerError, err.Error())
 		return
 	}
+	if !checkChannelScope(request, response, d.ChannelID) {
+		return
+	}
+
+	if d.Schedule != "" {
+		d.Schedule = scheduler.WithStart(d.Schedule, time.Now())
+		if _, err := scheduler.Parse(d.Schedule); err != nil {
+			response.AddHeader("Content-Type", "text/plain")
+			response.WriteErrorString(http.StatusBadRequest, "400: "+err.Error())
+			return
+		}
+	}
+
 	err = storage.StoreDailyMeeting(*d)
 	if err != nil {
 		log.Printf("apiserver: error createing daily meeting for channel %s: %v", d.ChannelID, err)
@@ -157,97 +556,898 @@ func (dao DAO) findDailyMeetingsByBot(request *restful.Request, response *restfu
 		return
 
 	}
+
+	// Storage isn't filtering by bot, at least keep other teams' meetings out
+	key := requestAPIKey(request)
+	inScope := teamDailyMeetings[:0]
+	for _, d := range teamDailyMeetings {
+		if isChannelInScope(key, d.ChannelID) {
+			inScope = append(inScope, d)
+		}
+	}
+	teamDailyMeetings = inScope
+
 	response.WriteEntity(teamDailyMeetings)
 	log.Printf("apiserver: %d daily meetings found by bot %s", len(teamDailyMeetings), botID)
 }
 
-func (dao *DAO) createChannel(request *restful.Request, response *restful.Response) {
-	c := new(api.Channel)
-	err := request.ReadEntity(c)
+func (dao DAO) findDailyOrder(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	var d api.DailyMeeting
+	if err := storage.GetDailyMeeting(channelID, &d); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meeting could not be found.")
+		return
+	}
+	response.WriteEntity(api.DailyOrder{Order: d.Order, ManualOrder: d.ManualOrder})
+}
+
+func (dao DAO) findNextDaily(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	var d api.DailyMeeting
+	if err := storage.GetDailyMeeting(channelID, &d); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meeting could not be found.")
+		return
+	}
+
+	s, err := scheduler.ForDaily(d)
 	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meeting isn't scheduled.")
+		return
+	}
+
+	next := s.Next(time.Now())
+	if next.IsZero() {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meeting hasn't more occurrences.")
+		return
+	}
+	response.WriteEntity(api.NextDaily{ChannelID: channelID, Schedule: d.Schedule, Next: next})
+}
+
+func (dao *DAO) updateDailyOrder(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	o := new(api.DailyOrder)
+	if err := request.ReadEntity(o); err != nil {
 		response.AddHeader("Content-Type", "text/plain")
 		response.WriteErrorString(http.StatusInternalServerError, err.Error())
 		return
 	}
-	err = storage.StoreChannel(*c)
-	if err != nil {
+
+	if !api.IsValidOrder(o.Order) || (o.Order == api.OrderManual && len(o.ManualOrder) == 0) {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: order must be random, rotation, alphabetical "+
+			"or manual with the list of member IDs.")
+		return
+	}
+
+	// The Daily Meeting may not be scheduled yet
+	d := api.DailyMeeting{ChannelID: channelID}
+	_ = storage.GetDailyMeeting(channelID, &d)
+	d.Order = o.Order
+	d.ManualOrder = o.ManualOrder
+
+	if err := storage.StoreDailyMeeting(d); err != nil {
+		log.Printf("apiserver: error updating order of daily meeting for channel %s: %v", channelID, err)
 		response.AddHeader("Content-Type", "text/plain")
 		response.WriteErrorString(http.StatusInternalServerError, err.Error())
 		return
+	}
+	response.WriteEntity(o)
+	log.Printf("apiserver: order of daily meeting for channel %s updated to %s", channelID, o.Order)
+}
 
+func (dao *DAO) storeDailyProgress(request *restful.Request, response *restful.Response) {
+	progress := new(api.DailyProgress)
+	if err := request.ReadEntity(progress); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
 	}
-	response.WriteHeaderAndEntity(http.StatusCreated, c)
-	log.Printf("apiserver: channel %s created", c.ID)
+	if !checkChannelScope(request, response, progress.ChannelID) {
+		return
+	}
+
+	if err := storage.StoreDailyProgress(*progress); err != nil {
+		log.Printf("apiserver: error storing daily progress for channel %s: %v", progress.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, progress)
 }
 
-func (dao DAO) findMember(request *restful.Request, response *restful.Response) {
+func (dao DAO) findDailyProgresses(request *restful.Request, response *restful.Response) {
 
-	channelID := request.PathParameter("channel-id")
-	memberID := request.PathParameter("member-id")
-	m, err := storage.GetMemberByName(channelID, memberID)
-	if err != nil {
+	var progresses []api.DailyProgress
+	if err := storage.GetDailyProgresses(&progresses); err != nil {
 		response.AddHeader("Content-Type", "text/plain")
-		response.WriteErrorString(http.StatusNotFound, "404: Member could not be found.")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meetings in progress could not be found.")
 		return
+	}
 
+	key := requestAPIKey(request)
+	inScope := []api.DailyProgress{}
+	for _, p := range progresses {
+		if isChannelInScope(key, p.ChannelID) {
+			inScope = append(inScope, p)
+		}
 	}
-	response.WriteEntity(m)
-	log.Printf("apiserver: member %s found", m.Name)
+	response.WriteEntity(inScope)
 }
 
-func (dao DAO) findMembersByChannel(request *restful.Request, response *restful.Response) {
+func (dao *DAO) removeDailyProgress(request *restful.Request, response *restful.Response) {
 
 	channelID := request.PathParameter("channel-id")
-	var teamMembers []api.Member
-	if err := storage.GetMembersByChannel(channelID, &teamMembers); err != nil {
+	if err := storage.DeleteDailyProgress(channelID); err != nil {
 		response.AddHeader("Content-Type", "text/plain")
-		response.WriteErrorString(http.StatusNotFound, "404: Member could not be found.")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meeting in progress could not be found.")
 		return
-
 	}
-	response.WriteEntity(teamMembers)
-	log.Printf("apiserver: %d members found by channel %s", len(teamMembers), channelID)
 }
 
-func (dao *DAO) createMember(request *restful.Request, response *restful.Response) {
-	m := new(api.Member)
-	err := request.ReadEntity(m)
-	if err != nil {
+func (dao *DAO) storeDailyDigest(request *restful.Request, response *restful.Response) {
+	digest := new(api.DailyDigest)
+	if err := request.ReadEntity(digest); err != nil {
 		response.AddHeader("Content-Type", "text/plain")
 		response.WriteErrorString(http.StatusInternalServerError, err.Error())
 		return
 	}
-	err = storage.StoreMember(*m)
-	if err != nil {
+	if !checkChannelScope(request, response, digest.ChannelID) {
+		return
+	}
+
+	if digest.Date.IsZero() {
+		digest.Date = time.Now()
+	}
+	y, m, d := digest.Date.Date()
+	digest.Date = time.Date(y, m, d, 0, 0, 0, 0, digest.Date.Location())
+
+	if err := storage.StoreDailyDigest(*digest); err != nil {
+		log.Printf("apiserver: error storing daily digest for channel %s: %v", digest.ChannelID, err)
 		response.AddHeader("Content-Type", "text/plain")
 		response.WriteErrorString(http.StatusInternalServerError, err.Error())
 		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, digest)
+}
+
+func (dao DAO) findDailyDigest(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	date, err := time.ParseInLocation("2006-01-02", request.PathParameter("date"), time.Local)
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: date must be like 2017-03-21.")
+		return
+	}
 
+	digest := new(api.DailyDigest)
+	if err := storage.GetDailyDigest(channelID, date, digest); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meeting could not be found.")
+		return
 	}
-	response.WriteHeaderAndEntity(http.StatusCreated, m)
-	log.Printf("apiserver: member %s created", m.Name)
+	response.WriteEntity(digest)
 }
 
-func (dao *DAO) removeMember(request *restful.Request, response *restful.Response) {
-	memberID := request.PathParameter("member-id")
+func (dao *DAO) addDigestAnswers(request *restful.Request, response *restful.Response) {
+
 	channelID := request.PathParameter("channel-id")
-	err := storage.DeleteMember(channelID, memberID)
+	if !checkChannelScope(request, response, channelID) {
+		return
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", request.PathParameter("date"), time.Local)
 	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: date must be like 2017-03-21.")
+		return
+	}
+
+	var answers []api.DailyAnswer
+	if err := request.ReadEntity(&answers); err != nil {
 		response.AddHeader("Content-Type", "text/plain")
 		response.WriteErrorString(http.StatusInternalServerError, err.Error())
 		return
 	}
-	log.Printf("apiserver: member %s deleted", memberID)
+
+	if err := storage.AddDigestAnswers(channelID, date, answers); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Daily Meeting could not be found.")
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, answers)
 }
 
-func (dao *DAO) storePredefinedReply(request *restful.Request, response *restful.Response) {
-	r := new(api.PredefinedDailyReply)
-	err := request.ReadEntity(r)
-	if err != nil {
+func (dao *DAO) createMeeting(request *restful.Request, response *restful.Response) {
+	mt := new(api.Meeting)
+	if err := request.ReadEntity(mt); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, mt.ChannelID) {
+		return
+	}
+
+	if !api.IsValidMeetingType(mt.Type) {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: type must be daily, retrospective, planning or checkin.")
+		return
+	}
+
+	mt.Schedule = scheduler.WithStart(mt.Schedule, time.Now())
+	if _, err := scheduler.Parse(mt.Schedule); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: "+err.Error())
+		return
+	}
+
+	// New meetings are numbered by type: retrospective-1, retrospective-2...
+	if mt.ID == "" {
+		var meetings []api.Meeting
+		_ = storage.GetMeetings(mt.ChannelID, &meetings)
+		used := map[string]bool{}
+		for _, existing := range meetings {
+			used[existing.ID] = true
+		}
+		for i := 1; mt.ID == "" || used[mt.ID]; i++ {
+			mt.ID = mt.Type + "-" + strconv.Itoa(i)
+		}
+	}
+
+	if err := storage.StoreMeeting(*mt); err != nil {
+		log.Printf("apiserver: error creating meeting %s for channel %s: %v", mt.ID, mt.ChannelID, err)
 		response.AddHeader("Content-Type", "text/plain")
 		response.WriteErrorString(http.StatusInternalServerError, err.Error())
 		return
 	}
+	response.WriteHeaderAndEntity(http.StatusCreated, mt)
+	log.Printf("apiserver: meeting %s for channel %s created", mt.ID, mt.ChannelID)
+}
+
+func (dao DAO) findMeetings(request *restful.Request, response *restful.Response) {
+
+	var meetings []api.Meeting
+	if err := storage.GetMeetings("", &meetings); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Meetings could not be found.")
+		return
+	}
+
+	key := requestAPIKey(request)
+	inScope := []api.Meeting{}
+	for _, mt := range meetings {
+		if isChannelInScope(key, mt.ChannelID) {
+			inScope = append(inScope, mt)
+		}
+	}
+	response.WriteEntity(inScope)
+}
+
+func (dao DAO) findMeetingsByChannel(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	meetings := []api.Meeting{}
+	if err := storage.GetMeetings(channelID, &meetings); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Meetings could not be found.")
+		return
+	}
+	response.WriteEntity(meetings)
+}
+
+func (dao *DAO) removeMeeting(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	meetingID := request.PathParameter("meeting-id")
+	if err := storage.DeleteMeeting(channelID, meetingID); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Meeting could not be found.")
+		return
+	}
+	log.Printf("apiserver: meeting %s of channel %s deleted", meetingID, channelID)
+}
+
+func (dao *DAO) createRetroEntry(request *restful.Request, response *restful.Response) {
+	entry := new(api.RetroEntry)
+	if err := request.ReadEntity(entry); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, entry.ChannelID) {
+		return
+	}
+
+	if err := storage.StoreRetroEntry(entry); err != nil {
+		log.Printf("apiserver: error creating retro entry for channel %s: %v", entry.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, entry)
+}
+
+func (dao DAO) findRetroEntriesByChannel(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	entries := []api.RetroEntry{}
+	if err := storage.GetRetroEntries(channelID, &entries); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Retro entries could not be found.")
+		return
+	}
+	response.WriteEntity(entries)
+}
+
+func (dao *DAO) createActionItem(request *restful.Request, response *restful.Response) {
+	item := new(api.ActionItem)
+	if err := request.ReadEntity(item); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, item.ChannelID) {
+		return
+	}
+
+	if err := storage.StoreActionItem(item); err != nil {
+		log.Printf("apiserver: error creating action item for channel %s: %v", item.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, item)
+	log.Printf("apiserver: action item %d for channel %s stored", item.ID, item.ChannelID)
+}
+
+func (dao DAO) findActionItemsByChannel(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	items := []api.ActionItem{}
+	if err := storage.GetActionItems(channelID, &items); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Action items could not be found.")
+		return
+	}
+	response.WriteEntity(items)
+}
+
+func (dao *DAO) createReminder(request *restful.Request, response *restful.Response) {
+	r := new(api.Reminder)
+	if err := request.ReadEntity(r); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, r.ChannelID) {
+		return
+	}
+	if r.TargetChannel != "" && !checkChannelScope(request, response, r.TargetChannel) {
+		return
+	}
+
+	if r.Text == "" || (r.TargetChannel == "") == (r.TargetMember == "") {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: text and either targetChannel or targetMember are required.")
+		return
+	}
+
+	if r.Schedule != "" {
+		r.Schedule = scheduler.WithStart(r.Schedule, time.Now())
+		if _, err := scheduler.Parse(r.Schedule); err != nil {
+			response.AddHeader("Content-Type", "text/plain")
+			response.WriteErrorString(http.StatusBadRequest, "400: "+err.Error())
+			return
+		}
+	} else if r.At.IsZero() {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: schedule or at is required.")
+		return
+	}
+
+	// New reminders only count the occurrences from now on
+	if r.ID == 0 && r.LastRun.IsZero() {
+		r.LastRun = time.Now()
+	}
+
+	if err := storage.StoreReminder(r); err != nil {
+		log.Printf("apiserver: error creating reminder for channel %s: %v", r.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, r)
+	log.Printf("apiserver: reminder %d for channel %s stored", r.ID, r.ChannelID)
+}
+
+func (dao DAO) findReminders(request *restful.Request, response *restful.Response) {
+
+	var reminders []api.Reminder
+	if err := storage.GetReminders("", &reminders); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Reminders could not be found.")
+		return
+	}
+
+	key := requestAPIKey(request)
+	inScope := []api.Reminder{}
+	for _, r := range reminders {
+		if isChannelInScope(key, r.ChannelID) {
+			inScope = append(inScope, r)
+		}
+	}
+	response.WriteEntity(inScope)
+}
+
+func (dao DAO) findRemindersByChannel(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	reminders := []api.Reminder{}
+	if err := storage.GetReminders(channelID, &reminders); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Reminders could not be found.")
+		return
+	}
+	response.WriteEntity(reminders)
+}
+
+func (dao *DAO) removeReminder(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	reminderID, err := strconv.Atoi(request.PathParameter("reminder-id"))
+	if err == nil {
+		err = storage.DeleteReminder(channelID, reminderID)
+	}
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Reminder could not be found.")
+		return
+	}
+	log.Printf("apiserver: reminder %d of channel %s deleted", reminderID, channelID)
+}
+
+func (dao *DAO) createTimesheet(request *restful.Request, response *restful.Response) {
+	t := new(api.Timesheet)
+	if err := request.ReadEntity(t); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, t.ChannelID) {
+		return
+	}
+
+	if t.Week == "" {
+		t.Week = api.Week(time.Now())
+	}
+	valid := t.MemberID != "" && weekPattern.MatchString(t.Week)
+	for _, e := range t.Entries {
+		if e.Project == "" || e.Hours < 0 {
+			valid = false
+		}
+	}
+	if !valid {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: memberId is required, week must be like 2017-W12 "+
+			"and each entry needs a project and positive hours.")
+		return
+	}
+	t.Submitted = time.Now()
+
+	if err := storage.StoreTimesheet(*t); err != nil {
+		log.Printf("apiserver: error storing timesheet for channel %s: %v", t.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, t)
+}
+
+func (dao DAO) findTimesheets(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	week := request.QueryParameter("week")
+	if week == "" {
+		week = api.Week(time.Now())
+	} else if !weekPattern.MatchString(week) {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: week must be like 2017-W12.")
+		return
+	}
+
+	timesheets := []api.Timesheet{}
+	if err := storage.GetTimesheets(channelID, week, &timesheets); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Timesheets could not be found.")
+		return
+	}
+
+	if strings.Contains(request.HeaderParameter("Accept"), restful.MIME_JSON) {
+		response.WriteEntity(timesheets)
+		return
+	}
+
+	var members []api.Member
+	_ = storage.GetMembersByChannel(channelID, &members)
+	names := map[string]string{}
+	for _, m := range members {
+		names[m.ID] = m.Name
+	}
+
+	response.AddHeader("Content-Type", "text/csv")
+	response.AddHeader("Content-Disposition", "attachment; filename=timesheets-"+channelID+"-"+week+".csv")
+	w := csv.NewWriter(response)
+	w.Write([]string{"week", "member", "name", "project", "hours"})
+	for _, t := range timesheets {
+		for _, e := range t.Entries {
+			w.Write([]string{t.Week, t.MemberID, names[t.MemberID], e.Project, strconv.FormatFloat(e.Hours, 'f', -1, 64)})
+		}
+	}
+	w.Flush()
+}
+
+// getTemplates returns the templates of every message in the channel, the overridden ones or the default ones
+func getTemplates(channelID string) (map[string]api.MessageTemplate, error) {
+	var overridden []api.MessageTemplate
+	if err := storage.GetTemplates(channelID, &overridden); err != nil {
+		return nil, err
+	}
+
+	templates := map[string]api.MessageTemplate{}
+	for key, t := range api.DefaultTemplates {
+		templates[key] = api.MessageTemplate{ChannelID: channelID, Key: key, Template: t, Default: true}
+	}
+	for _, t := range overridden {
+		templates[t.Key] = t
+	}
+	return templates, nil
+}
+
+func (dao DAO) findTemplatesByChannel(request *restful.Request, response *restful.Response) {
+
+	templates, err := getTemplates(request.PathParameter("channel-id"))
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Templates could not be found.")
+		return
+	}
+
+	keys := make([]string, 0, len(templates))
+	for key := range templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := make([]api.MessageTemplate, len(keys))
+	for i, key := range keys {
+		list[i] = templates[key]
+	}
+	response.WriteEntity(list)
+}
+
+func (dao DAO) findTemplate(request *restful.Request, response *restful.Response) {
+
+	templates, err := getTemplates(request.PathParameter("channel-id"))
+	t, ok := templates[request.PathParameter("message-key")]
+	if err != nil || !ok {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Template could not be found.")
+		return
+	}
+	response.WriteEntity(t)
+}
+
+func (dao *DAO) updateTemplate(request *restful.Request, response *restful.Response) {
+	t := new(api.MessageTemplate)
+	if err := request.ReadEntity(t); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	t.ChannelID = request.PathParameter("channel-id")
+	t.Key = request.PathParameter("message-key")
+	t.Default = false
+	if !checkChannelScope(request, response, t.ChannelID) {
+		return
+	}
+
+	if _, ok := api.DefaultTemplates[t.Key]; !ok {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: There isn't any message with key "+t.Key+".")
+		return
+	}
+	if _, err := template.New(t.Key).Parse(t.Template); err != nil || strings.TrimSpace(t.Template) == "" {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: The template is empty or not valid.")
+		return
+	}
+
+	if err := storage.StoreTemplate(t); err != nil {
+		log.Printf("apiserver: error storing template %s for channel %s: %v", t.Key, t.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteEntity(t)
+	log.Printf("apiserver: template %s for channel %s stored", t.Key, t.ChannelID)
+}
+
+func (dao *DAO) removeTemplate(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	key := request.PathParameter("message-key")
+	if err := storage.DeleteTemplate(channelID, key); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Template could not be found.")
+		return
+	}
+	log.Printf("apiserver: template %s of channel %s deleted", key, channelID)
+}
+
+func (dao *DAO) createAbsence(request *restful.Request, response *restful.Response) {
+	a := new(api.Absence)
+	if err := request.ReadEntity(a); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, a.ChannelID) {
+		return
+	}
+
+	if a.To.IsZero() {
+		a.To = a.From
+	}
+	if a.MemberID == "" || a.From.IsZero() || a.To.Before(a.From) {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: memberId and from are required, to can't be before from.")
+		return
+	}
+
+	if err := storage.StoreAbsence(a); err != nil {
+		log.Printf("apiserver: error creating absence for channel %s: %v", a.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, a)
+	log.Printf("apiserver: absence %d for channel %s stored", a.ID, a.ChannelID)
+}
+
+func (dao DAO) findAbsencesByChannel(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	absences := []api.Absence{}
+	if err := storage.GetAbsences(channelID, &absences); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Absences could not be found.")
+		return
+	}
+	response.WriteEntity(absences)
+}
+
+func (dao *DAO) removeAbsence(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	absenceID, err := strconv.Atoi(request.PathParameter("absence-id"))
+	if err == nil {
+		err = storage.DeleteAbsence(channelID, absenceID)
+	}
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Absence could not be found.")
+		return
+	}
+	log.Printf("apiserver: absence %d of channel %s deleted", absenceID, channelID)
+}
+
+func (dao *DAO) createMood(request *restful.Request, response *restful.Response) {
+	mood := new(api.Mood)
+	if err := request.ReadEntity(mood); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, mood.ChannelID) {
+		return
+	}
+
+	if mood.MemberID == "" || mood.Score < api.MinMood || mood.Score > api.MaxMood {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: memberId is required and score must be between 1 and 5.")
+		return
+	}
+
+	if mood.Date.IsZero() {
+		mood.Date = time.Now()
+	}
+	y, m, d := mood.Date.Date()
+	mood.Date = time.Date(y, m, d, 0, 0, 0, 0, mood.Date.Location())
+
+	if err := storage.StoreMood(*mood); err != nil {
+		log.Printf("apiserver: error storing mood for channel %s: %v", mood.ChannelID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, mood)
+}
+
+func (dao DAO) findMoodTrend(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+
+	days := 30
+	if d := request.QueryParameter("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days < 1 {
+			response.AddHeader("Content-Type", "text/plain")
+			response.WriteErrorString(http.StatusBadRequest, "400: days must be a positive number.")
+			return
+		}
+	}
+
+	y, m, d := time.Now().AddDate(0, 0, 1-days).Date()
+	var moods []api.Mood
+	if err := storage.GetMoods(channelID, time.Date(y, m, d, 0, 0, 0, 0, time.Local), &moods); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Moods could not be found.")
+		return
+	}
+
+	response.WriteEntity(moodTrend(moods))
+}
+
+// moodTrend averages the moods, sorted by day, of each day with api.MinMoodAnswers or more. The answers of the days
+// with fewer are added to the next day, and to the last average at the end, so the score of a member is never seen
+func moodTrend(moods []api.Mood) []api.MoodTrend {
+	trend := []api.MoodTrend{}
+	var bucket api.MoodTrend
+	for i, mood := range moods {
+		bucket.Date = mood.Date
+		bucket.Average += float64(mood.Score)
+		bucket.Answers++
+
+		if dayEnds := i == len(moods)-1 || !moods[i+1].Date.Equal(mood.Date); dayEnds &&
+			bucket.Answers >= api.MinMoodAnswers {
+			trend = append(trend, bucket)
+			bucket = api.MoodTrend{}
+		}
+	}
+
+	if last := len(trend) - 1; bucket.Answers > 0 && last >= 0 {
+		trend[last].Average += bucket.Average
+		trend[last].Answers += bucket.Answers
+	}
+	for i := range trend {
+		trend[i].Average /= float64(trend[i].Answers)
+	}
+	return trend
+}
+
+func (dao *DAO) createChannel(request *restful.Request, response *restful.Response) {
+	c := new(api.Channel)
+	err := request.ReadEntity(c)
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// New channels are registered in the team of the key, existing ones can't change of team
+	key := requestAPIKey(request)
+	if _, err := storage.GetChannel(c.ID); err == nil && !isChannelInScope(key, c.ID) ||
+		!key.IsScopedTo(c.TeamID) {
+		writeOutOfScope(response)
+		return
+	}
+	if !dao.checkTeam(request, response, c.TeamID) {
+		return
+	}
+	err = storage.StoreChannel(*c)
+	if _, ok := err.(storage.ReservedChannelError); ok {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: "+err.Error())
+		return
+	}
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, c)
+	log.Printf("apiserver: channel %s created", c.ID)
+}
+
+func (dao DAO) findMember(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	memberID := request.PathParameter("member-id")
+	m, err := storage.GetMemberByName(channelID, memberID)
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Member could not be found.")
+		return
+
+	}
+	response.WriteEntity(m)
+	log.Printf("apiserver: member %s found", m.Name)
+}
+
+func (dao DAO) findMembersByChannel(request *restful.Request, response *restful.Response) {
+
+	channelID := request.PathParameter("channel-id")
+	var teamMembers []api.Member
+	if err := storage.GetMembersByChannel(channelID, &teamMembers); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: Member could not be found.")
+		return
+
+	}
+	response.WriteEntity(teamMembers)
+	log.Printf("apiserver: %d members found by channel %s", len(teamMembers), channelID)
+}
+
+func (dao *DAO) createMember(request *restful.Request, response *restful.Response) {
+	m := new(api.Member)
+	err := request.ReadEntity(m)
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, m.ChannelID) || !dao.checkTeam(request, response, m.TeamID) {
+		return
+	}
+	err = storage.StoreMember(*m)
+	if _, ok := err.(storage.ReservedChannelError); ok {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: "+err.Error())
+		return
+	}
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, m)
+	log.Printf("apiserver: member %s created", m.Name)
+}
+
+func (dao *DAO) removeMember(request *restful.Request, response *restful.Response) {
+	memberID := request.PathParameter("member-id")
+	channelID := request.PathParameter("channel-id")
+	err := storage.DeleteMember(channelID, memberID)
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("apiserver: member %s deleted", memberID)
+}
+
+func (dao *DAO) storePredefinedReply(request *restful.Request, response *restful.Response) {
+	r := new(api.PredefinedDailyReply)
+	err := request.ReadEntity(r)
+	if err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !checkChannelScope(request, response, r.ChannelID) {
+		return
+	}
 	err = storage.StorePredefinedReply(*r)
 	if err != nil {
 		response.AddHeader("Content-Type", "text/plain")
@@ -286,8 +1486,8 @@ func (dao DAO) deletePredefinedRepliesByChannel(request *restful.Request, respon
 	log.Printf("apiserver: predefined replies on channel %s deleted", channelID)
 }
 
-// LaunchAPIServer is invoked by CLI to initiate the API Server
-func LaunchAPIServer(pathDbArg, dbNameArg, hostArg string, portArg int) {
+// LaunchAPIServer is invoked by CLI to initiate the API Server, masterKeyArg gives access to all the teams
+func LaunchAPIServer(pathDbArg, dbNameArg, hostArg string, portArg int, masterKeyArg string) {
 
 	// Parameters
 	portStr := strconv.Itoa(portArg)
@@ -304,7 +1504,7 @@ func LaunchAPIServer(pathDbArg, dbNameArg, hostArg string, portArg int) {
 	restful.TraceLogger(log.New(os.Stdout, "apiserver: ", log.LstdFlags|log.Lshortfile))
 
 	wsContainer := restful.NewContainer()
-	dao := DAO{}
+	dao := DAO{masterKey: masterKeyArg}
 	dao.register(wsContainer)
 
 	config := swagger.Config{
@@ -0,0 +1,55 @@
+package apiserver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+func TestMoodTrend(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2017, time.March, d, 0, 0, 0, 0, time.UTC) }
+	moods := func(d int, scores ...int) []api.Mood {
+		var m []api.Mood
+		for _, s := range scores {
+			m = append(m, api.Mood{Date: day(d), Score: s})
+		}
+		return m
+	}
+	join := func(days ...[]api.Mood) []api.Mood {
+		var m []api.Mood
+		for _, d := range days {
+			m = append(m, d...)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name  string
+		moods []api.Mood
+		trend []api.MoodTrend
+	}{
+		{"no answers", nil, []api.MoodTrend{}},
+		{"fewer answers than the minimum", moods(20, 1, 5), []api.MoodTrend{}},
+		{"one day", moods(20, 1, 2, 3), []api.MoodTrend{{Date: day(20), Average: 2, Answers: 3}}},
+		{"days with enough answers",
+			join(moods(20, 4, 4, 4), moods(21, 1, 2, 3, 2)),
+			[]api.MoodTrend{{Date: day(20), Average: 4, Answers: 3}, {Date: day(21), Average: 2, Answers: 4}}},
+		{"a single answer goes to the next day",
+			join(moods(20, 1), moods(21, 5, 5, 5)),
+			[]api.MoodTrend{{Date: day(21), Average: 4, Answers: 4}}},
+		{"small days are added until the minimum",
+			join(moods(20, 2), moods(21, 3), moods(22, 4), moods(23, 5, 5, 5)),
+			[]api.MoodTrend{{Date: day(22), Average: 3, Answers: 3}, {Date: day(23), Average: 5, Answers: 3}}},
+		{"a small last day goes to the previous average",
+			join(moods(20, 3, 3, 3), moods(21, 5)),
+			[]api.MoodTrend{{Date: day(20), Average: 3.5, Answers: 4}}},
+	}
+
+	for _, tt := range tests {
+		if got := moodTrend(tt.moods); !reflect.DeepEqual(got, tt.trend) {
+			t.Errorf("%s: moodTrend() = %+v, want %+v", tt.name, got, tt.trend)
+		}
+	}
+}
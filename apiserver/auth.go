@@ -0,0 +1,204 @@
+// Package apiserver provides the APIs to build the leanmanager logic
+package apiserver
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/storage"
+	"github.com/emicklei/go-restful"
+)
+
+const attributeAPIKey = "apiKey"
+
+// GenerateKey returns a new random key to authenticate in the API Server
+func GenerateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// authenticate is the filter of the web services: it validates the key sent as bearer token or X-API-Key header,
+// its permission for the method and, when the route has one, the team of the bot or the channel
+func (dao DAO) authenticate(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+
+	key, ok := dao.findAPIKey(getCredential(request))
+	if !ok {
+		response.AddHeader("WWW-Authenticate", `Bearer realm="leanmanager"`)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusUnauthorized, "401: a valid API key is required.")
+		return
+	}
+
+	if request.Request.Method != http.MethodGet && !key.CanWrite() {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusForbidden, "403: the API key is read only.")
+		return
+	}
+
+	if botID := request.PathParameter("bot-id"); botID != "" && !key.IsScopedTo(botID) {
+		writeOutOfScope(response)
+		return
+	}
+
+	if channelID := request.PathParameter("channel-id"); channelID != "" && !isChannelInScope(key, channelID) {
+		writeOutOfScope(response)
+		return
+	}
+
+	request.SetAttribute(attributeAPIKey, key)
+	chain.ProcessFilter(request, response)
+}
+
+// getCredential reads the key from the Authorization: Bearer or X-API-Key headers
+func getCredential(request *restful.Request) string {
+	if auth := request.HeaderParameter("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return strings.TrimSpace(request.HeaderParameter("X-API-Key"))
+}
+
+func (dao DAO) findAPIKey(credential string) (api.APIKey, bool) {
+	if credential == "" {
+		return api.APIKey{}, false
+	}
+
+	if dao.masterKey != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(dao.masterKey)) == 1 {
+		return api.APIKey{Key: credential, TeamID: api.AllTeams, Permission: api.PermissionWrite}, true
+	}
+
+	// Only the master key is for all the teams, a stored key claiming it wasn't created by createAPIKey
+	key, err := storage.GetAPIKey(credential)
+	if err != nil || key.TeamID == api.AllTeams {
+		return api.APIKey{}, false
+	}
+	return *key, true
+}
+
+// isMasterKey compares the key with the one given to the API Server, never trusting its stored team
+func (dao DAO) isMasterKey(key api.APIKey) bool {
+	return dao.masterKey != "" && subtle.ConstantTimeCompare([]byte(key.Key), []byte(dao.masterKey)) == 1
+}
+
+// isChannelInScope checks the team which registered the channel, unknown channels are only for the master key
+func isChannelInScope(key api.APIKey, channelID string) bool {
+	if key.TeamID == api.AllTeams {
+		return true
+	}
+
+	c, err := storage.GetChannel(channelID)
+	if err != nil {
+		return false
+	}
+	return key.IsScopedTo(c.TeamID)
+}
+
+// requestAPIKey returns the key validated by the filter
+func requestAPIKey(request *restful.Request) api.APIKey {
+	key, _ := request.Attribute(attributeAPIKey).(api.APIKey)
+	return key
+}
+
+// checkChannelScope is used by routes receiving the channel in the body, writing the error if it's out of scope
+func checkChannelScope(request *restful.Request, response *restful.Response, channelID string) bool {
+	if !isChannelInScope(requestAPIKey(request), channelID) {
+		writeOutOfScope(response)
+		return false
+	}
+	return true
+}
+
+// checkMasterKey protects the management of API keys
+func (dao DAO) checkMasterKey(request *restful.Request, response *restful.Response) bool {
+	if !dao.isMasterKey(requestAPIKey(request)) {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusForbidden, "403: only the master key can manage API keys.")
+		return false
+	}
+	return true
+}
+
+// checkTeam refuses the records claiming all the teams, only the master key can write them
+func (dao DAO) checkTeam(request *restful.Request, response *restful.Response, teamID string) bool {
+	if teamID == api.AllTeams && !dao.isMasterKey(requestAPIKey(request)) {
+		writeOutOfScope(response)
+		return false
+	}
+	return true
+}
+
+func writeOutOfScope(response *restful.Response) {
+	response.AddHeader("Content-Type", "text/plain")
+	response.WriteErrorString(http.StatusForbidden, "403: the API key doesn't give access to this team.")
+}
+
+func (dao *DAO) createAPIKey(request *restful.Request, response *restful.Response) {
+	if !dao.checkMasterKey(request, response) {
+		return
+	}
+
+	k := new(api.APIKey)
+	if err := request.ReadEntity(k); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if k.TeamID == "" || k.TeamID == api.AllTeams ||
+		(k.Permission != api.PermissionRead && k.Permission != api.PermissionWrite) {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusBadRequest, "400: teamId and permission (read or write) are required.")
+		return
+	}
+
+	var err error
+	if k.Key, err = GenerateKey(); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := storage.StoreAPIKey(*k); err != nil {
+		log.Printf("apiserver: error creating API key for team %s: %v", k.TeamID, err)
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, k)
+	log.Printf("apiserver: %s API key created for team %s", k.Permission, k.TeamID)
+}
+
+func (dao DAO) findAPIKeysByTeam(request *restful.Request, response *restful.Response) {
+	if !dao.checkMasterKey(request, response) {
+		return
+	}
+
+	teamID := request.PathParameter("team-id")
+	var keys []api.APIKey
+	if err := storage.GetAPIKeysByTeam(teamID, &keys); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: API keys could not be found.")
+		return
+	}
+	response.WriteEntity(keys)
+}
+
+func (dao *DAO) removeAPIKey(request *restful.Request, response *restful.Response) {
+	if !dao.checkMasterKey(request, response) {
+		return
+	}
+
+	if err := storage.DeleteAPIKey(request.PathParameter("key")); err != nil {
+		response.AddHeader("Content-Type", "text/plain")
+		response.WriteErrorString(http.StatusNotFound, "404: API key could not be found.")
+		return
+	}
+	log.Printf("apiserver: API key deleted")
+}
@@ -0,0 +1,90 @@
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/storage"
+	"github.com/emicklei/go-restful"
+)
+
+const testMasterKey = "master"
+
+// newTestServer serves the API with a fresh database, a write key for ATTACKER and another for VICTIM
+func newTestServer(t *testing.T) *httptest.Server {
+	if err := storage.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("error opening the database: %v", err)
+	}
+	t.Cleanup(func() { storage.CloseDB() })
+
+	for _, k := range []api.APIKey{
+		{Key: "attacker", TeamID: "ATTACKER", Permission: api.PermissionWrite},
+		{Key: "victim", TeamID: "VICTIM", Permission: api.PermissionWrite},
+	} {
+		if err := storage.StoreAPIKey(k); err != nil {
+			t.Fatalf("error storing key %s: %v", k.Key, err)
+		}
+	}
+
+	container := restful.NewContainer()
+	DAO{masterKey: testMasterKey}.register(container)
+	server := httptest.NewServer(container)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func doRequest(t *testing.T, server *httptest.Server, method, path, key, body string) int {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Content-Type", restful.MIME_JSON)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error calling %s %s: %v", method, path, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestTeamKeyCantForgeKeys(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		method, path, key, body string
+		status                  int
+	}{
+		{http.MethodPost, "/channels", "attacker", `{"id":"apikeys","teamId":"ATTACKER"}`, http.StatusBadRequest},
+		{http.MethodPost, "/channels", "attacker", `{"id":"C1","teamId":"*"}`, http.StatusForbidden},
+		{http.MethodPost, "/channels", "attacker", `{"id":"C1","teamId":"ATTACKER"}`, http.StatusCreated},
+		{http.MethodPost, "/members", "attacker", `{"id":"evil","channelId":"apikeys","teamId":"*"}`, http.StatusForbidden},
+		{http.MethodPost, "/members", "attacker", `{"id":"evil","channelId":"C1","teamId":"*"}`, http.StatusForbidden},
+		{http.MethodPost, "/members", "attacker", `{"id":"U1","channelId":"C1","teamId":"ATTACKER"}`, http.StatusCreated},
+		{http.MethodPost, "/members", testMasterKey, `{"id":"evil","channelId":"apikeys","teamId":"*"}`, http.StatusBadRequest},
+		{http.MethodGet, "/apikeys/VICTIM/", "evil", "", http.StatusUnauthorized},
+		{http.MethodGet, "/apikeys/VICTIM/", "attacker", "", http.StatusForbidden},
+		{http.MethodGet, "/apikeys/VICTIM/", testMasterKey, "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if got := doRequest(t, server, tt.method, tt.path, tt.key, tt.body); got != tt.status {
+			t.Errorf("%s %s with key %s and %s = %d, want %d", tt.method, tt.path, tt.key, tt.body, got, tt.status)
+		}
+	}
+}
+
+func TestStoredKeyForAllTeamsIsRefused(t *testing.T) {
+	server := newTestServer(t)
+
+	if err := storage.StoreAPIKey(api.APIKey{Key: "forged", TeamID: api.AllTeams, Permission: api.PermissionWrite}); err != nil {
+		t.Fatalf("error storing key: %v", err)
+	}
+	if got := doRequest(t, server, http.MethodGet, "/apikeys/VICTIM/", "forged", ""); got != http.StatusUnauthorized {
+		t.Errorf("GET /apikeys/VICTIM/ with a stored key for all teams = %d, want %d", got, http.StatusUnauthorized)
+	}
+}
@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"log"
 	"os"
 
 	"github.com/antonmry/leanmanager/apiserver"
@@ -19,7 +20,13 @@ var apiserverCmd = &cobra.Command{
 			pathDB = os.Getenv("LEANMANAGER_PATHDB")
 		}
 
-		apiserver.LaunchAPIServer(pathDB, dbName, apiserverHost, apiserverPort)
+		generated := apiKey == "" && os.Getenv("LEANMANAGER_API_KEY") == ""
+		key := getAPIKey(true)
+		if generated {
+			log.Printf("apiserver: no --apiKey provided, the master key is %s", key)
+		}
+
+		apiserver.LaunchAPIServer(pathDB, dbName, apiserverHost, apiserverPort, key)
 	},
 }
 
@@ -0,0 +1,45 @@
+// Package cmd implements the leanmanager available commands
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/antonmry/leanmanager/slackbot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mattermostURL   string
+	mattermostToken string
+)
+
+var mattermostbotCmd = &cobra.Command{
+	Use:   "mattermostbot",
+	Short: "Launch the bot and connect to Mattermost",
+	Long: `It will run the bot to receive and send messages to a Mattermost server instead of Slack. It will
+	use the API server provided to store the data.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mattermostURL == "" {
+			mattermostURL = os.Getenv("LEANMANAGER_MATTERMOST_URL")
+		}
+		if mattermostToken == "" {
+			mattermostToken = os.Getenv("LEANMANAGER_MATTERMOST_TOKEN")
+		}
+
+		if mattermostURL == "" || mattermostToken == "" {
+			log.SetFlags(0)
+			log.Fatal("Please, specify mattermostURL using -u and mattermostToken using -k")
+		}
+		slackbot.LaunchMattermostbot(mattermostURL, mattermostToken, teamName, apiserverHost, apiserverPort,
+			getAPIKey(false))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(mattermostbotCmd)
+
+	f := mattermostbotCmd.Flags()
+	f.StringVarP(&mattermostURL, "mattermostURL", "u", "", "URL of the Mattermost server, e.g. https://chat.example.com")
+	f.StringVarP(&mattermostToken, "mattermostToken", "k", "", "Access token of the Mattermost bot.")
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/antonmry/leanmanager/apiserver"
+	"github.com/antonmry/leanmanager/content"
 	"github.com/antonmry/leanmanager/slackbot"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +20,8 @@ var (
 	apiserverPort int
 	pathDB        string
 	dbName        string
+	apiKey        string
+	quotesDir     string
 )
 
 // RootCmd acts as an standalone instance launching all services to provide non-HA functionality
@@ -27,6 +30,12 @@ var RootCmd = &cobra.Command{
 	Short: "Replace your managers with a bot",
 	Long: `This bot automates the tasks usually done by managers in development teams, so you can save costs and
 	let your team work in more productive tasks than simple management.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if os.Getenv("LEANMANAGER_QUOTES_DIR") != "" && !cmd.Flags().Changed("quotesDir") {
+			quotesDir = os.Getenv("LEANMANAGER_QUOTES_DIR")
+		}
+		content.QuotesDir = quotesDir
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Args validation
 		if slackToken == "" {
@@ -41,21 +50,45 @@ var RootCmd = &cobra.Command{
 			pathDB = os.Getenv("LEANMANAGER_PATHDB")
 		}
 
+		key := getAPIKey(true)
+
 		// Launch Slackbot and API Server
 		var wg sync.WaitGroup
 		wg.Add(2)
 		go func() {
 			defer wg.Done()
-			slackbot.LaunchSlackbot(slackToken, teamName, apiserverHost, apiserverPort)
+			slackbot.LaunchSlackbot(slackToken, teamName, apiserverHost, apiserverPort, key)
 		}()
 		go func() {
 			defer wg.Done()
-			apiserver.LaunchAPIServer(pathDB, dbName, apiserverHost, apiserverPort)
+			apiserver.LaunchAPIServer(pathDB, dbName, apiserverHost, apiserverPort, key)
 		}()
 		wg.Wait()
 	},
 }
 
+// getAPIKey returns the key of the API Server from the flag or the environment. If there isn't any, it's
+// generated when allowed, otherwise it fails
+func getAPIKey(generate bool) string {
+	if apiKey == "" {
+		apiKey = os.Getenv("LEANMANAGER_API_KEY")
+	}
+	if apiKey != "" {
+		return apiKey
+	}
+
+	if !generate {
+		log.SetFlags(0)
+		log.Fatal("Please, specify the key of the API Server using --apiKey")
+	}
+
+	key, err := apiserver.GenerateKey()
+	if err != nil {
+		log.Fatalf("Error generating the key of the API Server: %v", err)
+	}
+	return key
+}
+
 // Execute is used by the root main to launch leanmanager commands
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
@@ -73,4 +106,6 @@ func init() {
 	f.StringVarP(&teamName, "teamName", "e", "YOURTEAMNAME", "Name of the bot's team.")
 	f.StringVarP(&apiserverHost, "apiserverHost", "a", "localhost", "IP or hostname of your leanmanager API server.")
 	f.IntVarP(&apiserverPort, "apiserverPort", "p", 8080, "IP or hostname of your leanmanager API server.")
+	f.StringVar(&quotesDir, "quotesDir", "quotes", "Directory of the quotes files shared at the end of the dailies.")
+	f.StringVar(&apiKey, "apiKey", "", "Master key of the API server, generated if empty when the server is launched.")
 }
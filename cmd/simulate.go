@@ -0,0 +1,76 @@
+// Package cmd implements the leanmanager available commands
+package cmd
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/antonmry/leanmanager/apiserver"
+	"github.com/antonmry/leanmanager/slackbot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulatorChannel string
+	simulatorVerbose bool
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Run the bot against a local chat simulator",
+	Long: `It will run the API Server and the bot connected to a fake chat in the terminal, where you can type
+	as different users (e.g. "as U123: yes") and watch the bot's messages. Useful to try schedules or
+	predefined replies without a Slack workspace.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if os.Getenv("LEANMANAGER_PATHDB") != "" && pathDB == "/tmp" {
+			pathDB = os.Getenv("LEANMANAGER_PATHDB")
+		}
+		if !cmd.Flags().Changed("dbname") {
+			dbName = "leanmanager-simulator"
+		}
+
+		// Keep the terminal for the simulated chat
+		out := os.Stdout
+		if !simulatorVerbose {
+			log.SetOutput(ioutil.Discard)
+			if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+				os.Stdout = devNull
+			}
+		}
+
+		key := getAPIKey(true)
+		go apiserver.LaunchAPIServer(pathDB, dbName, apiserverHost, apiserverPort, key)
+
+		if err := waitAPIServer(apiserverHost, apiserverPort); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Fatalf("API Server isn't available: %v", err)
+		}
+
+		slackbot.LaunchSimulator(os.Stdin, out, simulatorChannel, teamName, apiserverHost, apiserverPort, key)
+	},
+}
+
+func waitAPIServer(host string, port int) (err error) {
+	for i := 0; i < 50; i++ {
+		var resp *http.Response
+		if resp, err = http.Get("http://" + host + ":" + strconv.Itoa(port) + "/apidocs.json"); err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return err
+}
+
+func init() {
+	RootCmd.AddCommand(simulateCmd)
+
+	f := simulateCmd.Flags()
+	f.StringVarP(&simulatorChannel, "channel", "c", "CSIMULATOR", "ID of the simulated channel.")
+	f.BoolVarP(&simulatorVerbose, "verbose", "v", false, "Print the logs of the bot and the API Server.")
+}
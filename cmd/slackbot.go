@@ -23,7 +23,7 @@ var slackbotCmd = &cobra.Command{
 			log.SetFlags(0)
 			log.Fatal("Please, specify slackToken using -t or --slackToken")
 		}
-		slackbot.LaunchSlackbot(slackToken, teamName, apiserverHost, apiserverPort)
+		slackbot.LaunchSlackbot(slackToken, teamName, apiserverHost, apiserverPort, getAPIKey(false))
 	},
 }
 
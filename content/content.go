@@ -0,0 +1,161 @@
+// Package content provides the fun content, jokes, comics or quotes, shared at the end of the Daily Meetings
+package content
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// QuotesDir is the directory of the quotes files, the sources which aren't URLs are files in it
+var QuotesDir = "quotes"
+
+// ErrNoContent is returned when the source hasn't any item to share
+var ErrNoContent = errors.New("content: no items found")
+
+// Item is a piece of content: a joke, a comic, a quote...
+type Item struct {
+	Title string
+	Link  string
+}
+
+// String returns the item as a chat message, the link is unfurled by the chat
+func (i Item) String() string {
+	if i.Link == "" {
+		return i.Title
+	}
+	if i.Title == "" {
+		return i.Link
+	}
+	return i.Title + " " + i.Link
+}
+
+// Provider gives an item of content each time it's asked
+type Provider interface {
+	Item() (Item, error)
+}
+
+// New returns the provider of the source: http and https URLs are RSS or Atom feeds, anything else is the name of
+// a quotes file in QuotesDir
+func New(source string) (Provider, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return NewFeed(source), nil
+	}
+
+	// Only the files in QuotesDir can be read, the source comes from the chat
+	if source == "" || filepath.Base(source) != source || strings.HasPrefix(source, ".") {
+		return nil, fmt.Errorf("content: invalid source %q, it must be a URL or the name of a quotes file", source)
+	}
+	return NewQuotes(filepath.Join(QuotesDir, source)), nil
+}
+
+// Feed gives a random entry of an RSS or Atom feed
+type Feed struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewFeed returns the provider of the feed, with a timeout so a slow feed doesn't delay the Daily Meeting
+func NewFeed(url string) *Feed {
+	return &Feed{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type feedDocument struct {
+	// RSS
+	Items []struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+	} `xml:"channel>item"`
+	// Atom
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// Item fetches the feed and returns one of its entries
+func (f *Feed) Item() (Item, error) {
+	resp, err := f.Client.Get(f.URL)
+	if err != nil {
+		return Item{}, fmt.Errorf("content: error fetching feed %s: %v", f.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Item{}, fmt.Errorf("content: error fetching feed %s, status %d", f.URL, resp.StatusCode)
+	}
+
+	var doc feedDocument
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return Item{}, fmt.Errorf("content: error parsing feed %s: %v", f.URL, err)
+	}
+
+	var items []Item
+	for _, i := range doc.Items {
+		items = append(items, Item{Title: strings.TrimSpace(i.Title), Link: strings.TrimSpace(i.Link)})
+	}
+	for _, e := range doc.Entries {
+		item := Item{Title: strings.TrimSpace(e.Title)}
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				item.Link = l.Href
+				break
+			}
+		}
+		items = append(items, item)
+	}
+
+	return pick(items)
+}
+
+// Quotes gives a random line of a text file, empty lines and the ones starting with # are ignored
+type Quotes struct {
+	Path string
+}
+
+// NewQuotes returns the provider of the quotes file
+func NewQuotes(path string) *Quotes {
+	return &Quotes{Path: path}
+}
+
+// Item reads the file and returns one of its quotes
+func (q *Quotes) Item() (Item, error) {
+	file, err := os.Open(q.Path)
+	if err != nil {
+		return Item{}, fmt.Errorf("content: error opening quotes file: %v", err)
+	}
+	defer file.Close()
+
+	var items []Item
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		items = append(items, Item{Title: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return Item{}, fmt.Errorf("content: error reading quotes file %s: %v", q.Path, err)
+	}
+
+	return pick(items)
+}
+
+func pick(items []Item) (Item, error) {
+	if len(items) == 0 {
+		return Item{}, ErrNoContent
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return items[r.Intn(len(items))], nil
+}
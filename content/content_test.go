@@ -0,0 +1,119 @@
+package content
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// newFeedServer serves the files of testdata, as a feed would
+func newFeedServer() *httptest.Server {
+	return httptest.NewServer(http.FileServer(http.Dir("testdata")))
+}
+
+// collect asks the provider many times and returns the items given
+func collect(t *testing.T, p Provider) map[Item]bool {
+	found := map[Item]bool{}
+	for i := 0; i < 50; i++ {
+		item, err := p.Item()
+		if err != nil {
+			t.Fatalf("Item error: %v", err)
+		}
+		found[item] = true
+	}
+	return found
+}
+
+func checkItems(t *testing.T, source string, found map[Item]bool, want []Item) {
+	expected := map[Item]bool{}
+	for _, i := range want {
+		expected[i] = true
+	}
+	for i := range found {
+		if !expected[i] {
+			t.Errorf("%s gave unexpected item %+v", source, i)
+		}
+	}
+	if len(found) == 0 {
+		t.Errorf("%s gave no items", source)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	server := newFeedServer()
+	defer server.Close()
+
+	tests := []struct {
+		file  string
+		items []Item
+	}{
+		{"rss.xml", []Item{
+			{Title: "Why do programmers prefer dark mode?", Link: "https://jokes.example.com/dark-mode"},
+			{Title: "There are 10 kinds of people", Link: "https://jokes.example.com/binary"},
+		}},
+		{"atom.xml", []Item{
+			{Title: "Standup", Link: "https://comics.example.com/standup"},
+			{Title: "Estimates", Link: "https://comics.example.com/estimates"},
+		}},
+	}
+
+	for _, tt := range tests {
+		checkItems(t, tt.file, collect(t, NewFeed(server.URL+"/"+tt.file)), tt.items)
+	}
+}
+
+func TestFeedErrors(t *testing.T) {
+	server := newFeedServer()
+	defer server.Close()
+
+	for _, file := range []string{"missing.xml", "quotes.txt"} {
+		if item, err := NewFeed(server.URL + "/" + file).Item(); err == nil {
+			t.Errorf("feed %s gave %+v, want an error", file, item)
+		}
+	}
+
+	if _, err := NewFeed(server.URL + "/empty.xml").Item(); err != ErrNoContent {
+		t.Errorf("empty feed error = %v, want ErrNoContent", err)
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	checkItems(t, "quotes.txt", collect(t, NewQuotes(filepath.Join("testdata", "quotes.txt"))), []Item{
+		{Title: "Simplicity is prerequisite for reliability."},
+		{Title: "Talk is cheap. Show me the code."},
+		{Title: "Done is better than perfect."},
+	})
+
+	if _, err := NewQuotes(filepath.Join("testdata", "comments.txt")).Item(); err != ErrNoContent {
+		t.Errorf("quotes file without quotes error = %v, want ErrNoContent", err)
+	}
+	if _, err := NewQuotes(filepath.Join("testdata", "missing.txt")).Item(); err == nil {
+		t.Errorf("missing quotes file didn't fail")
+	}
+}
+
+func TestNew(t *testing.T) {
+	QuotesDir = "testdata"
+	defer func() { QuotesDir = "quotes" }()
+
+	if p, err := New("https://xkcd.com/atom.xml"); err != nil {
+		t.Errorf("New feed error: %v", err)
+	} else if _, ok := p.(*Feed); !ok {
+		t.Errorf("New feed = %T, want *Feed", p)
+	}
+
+	p, err := New("quotes.txt")
+	if err != nil {
+		t.Fatalf("New quotes error: %v", err)
+	}
+	if q, ok := p.(*Quotes); !ok || q.Path != filepath.Join("testdata", "quotes.txt") {
+		t.Errorf("New quotes = %+v, want the file in QuotesDir", p)
+	}
+
+	for _, source := range []string{"", "../content.go", "testdata/quotes.txt", ".hidden", "/etc/passwd"} {
+		if _, err := New(source); err == nil {
+			t.Errorf("New(%q) didn't fail", source)
+		}
+	}
+}
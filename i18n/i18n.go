@@ -0,0 +1,108 @@
+// Package i18n translates the messages of the bot and understands the words typed in each supported language
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is the language of the channels which haven't chosen one, the messages are written in it
+const DefaultLanguage = "en"
+
+// localeFiles are the catalogs, one per language named by its ISO 639-1 code, e.g. es.json
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// locale is the catalog of a language: its name, the words understood for each kind of answer and the translation
+// of each message, the English text is the key
+type locale struct {
+	Name     string              `json:"name"`
+	Words    map[string][]string `json:"words"`
+	Messages map[string]string   `json:"messages"`
+}
+
+var locales = map[string]locale{}
+
+func init() {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: error reading locales: %v", err))
+	}
+
+	for _, f := range files {
+		data, err := localeFiles.ReadFile("locales/" + f.Name())
+		if err != nil {
+			panic(fmt.Sprintf("i18n: error reading locale %s: %v", f.Name(), err))
+		}
+		var l locale
+		if err := json.Unmarshal(data, &l); err != nil {
+			panic(fmt.Sprintf("i18n: error parsing locale %s: %v", f.Name(), err))
+		}
+		locales[strings.TrimSuffix(f.Name(), path.Ext(f.Name()))] = l
+	}
+}
+
+// Languages returns the codes of the supported languages sorted
+func Languages() []string {
+	var langs []string
+	for lang := range locales {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// IsSupported returns true if there is a catalog for the language
+func IsSupported(lang string) bool {
+	_, ok := locales[lang]
+	return ok
+}
+
+// Name returns the name of the language in the language itself, e.g. español
+func Name(lang string) string {
+	if l, ok := locales[lang]; ok {
+		return l.Name
+	}
+	return lang
+}
+
+// T translates the text, formatting it with the args if there are any. The text is used as it is if the language
+// hasn't a translation for it
+func T(lang, text string, args ...interface{}) string {
+	if translated, ok := locales[lang].Messages[text]; ok && translated != "" {
+		text = translated
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Is returns true if the word is one of the given kind, e.g. yes, in any of the supported languages, so teams
+// mixing languages are understood too
+func Is(kind, word string) bool {
+	word = strings.TrimSpace(word)
+	for _, l := range locales {
+		for _, w := range l.Words[kind] {
+			if strings.EqualFold(w, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Kind returns which of the kinds given the word is, or an empty string if it isn't any of them
+func Kind(word string, kinds ...string) string {
+	for _, k := range kinds {
+		if Is(k, word) {
+			return k
+		}
+	}
+	return ""
+}
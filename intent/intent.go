@@ -0,0 +1,437 @@
+// Package intent reads the orders typed in free text, like `schedule the daily at 9:30 on weekdays`, and turns them
+// into the commands of the bot, or suggests the closest commands when the order is unknown
+package intent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/antonmry/leanmanager/i18n"
+)
+
+// Commands are the commands of the bot as typed after the mention, the suggestions are chosen from them
+var Commands = []string{
+	"hello",
+	"help",
+	"daily add member",
+	"daily add all",
+	"daily delete member",
+	"daily list members",
+	"daily role",
+	"daily start",
+	"daily info",
+	"daily schedule",
+	"daily threaded",
+	"daily mood",
+	"daily mood report",
+	"daily content",
+	"daily language",
+	"daily persona",
+	"daily timeout",
+	"daily order",
+	"daily resume",
+	"daily pending",
+	"daily skip",
+	"daily snooze",
+	"daily end",
+	"daily stop",
+	"daily add reply",
+	"daily delete reply",
+	"meeting add",
+	"meeting list",
+	"meeting start",
+	"meeting delete",
+	"action list",
+	"action done",
+	"remind",
+	"remind list",
+	"remind delete",
+	"timesheet schedule",
+	"timesheet hours",
+	"timesheet fill",
+	"timesheet report",
+	"morning",
+	"morning schedule",
+	"absent",
+	"absent list",
+	"absent delete",
+}
+
+// destructive are the commands which delete or interrupt something, they are confirmed before running them
+var destructive = map[string]bool{
+	"daily delete member": true,
+	"daily delete reply":  true,
+	"daily skip":          true,
+	"daily end":           true,
+	"daily stop":          true,
+	"meeting delete":      true,
+	"remind delete":       true,
+	"absent delete":       true,
+}
+
+// Intent is a command of the bot read from free text, with its arguments
+type Intent struct {
+	Command string
+	Args    []string
+}
+
+// IsDestructive returns true if the command deletes or interrupts something, so it shouldn't run from a text
+// misunderstood without asking first
+func (i Intent) IsDestructive() bool {
+	return destructive[i.Command]
+}
+
+// String returns the intent as it would be typed after the mention, e.g. `daily schedule weekdays 9:30`
+func (i Intent) String() string {
+	return strings.Join(append([]string{i.Command}, i.Args...), " ")
+}
+
+// Placeholders of the entities found in the text, they can be used in the words of the rules like any other word
+const (
+	memberWord   = "@member"
+	dayWord      = "@day"
+	timeWord     = "@time"
+	durationWord = "@duration"
+	numberWord   = "@number"
+)
+
+// entities is what is read from the text: its words, the members mentioned, the days of the week, the hour, a
+// duration and a number
+type entities struct {
+	words    map[string]bool
+	mentions []string
+	days     []string
+	time     string
+	duration string
+	number   string
+}
+
+// rule reads a command: the text must have one of the words of each group. args returns its arguments
+type rule struct {
+	command string
+	words   [][]string
+	args    func(e entities) []string
+}
+
+var (
+	daily    = []string{"daily", "standup", "stand-up", "scrum"}
+	members  = []string{"member", "members", "team", "people", memberWord}
+	list     = []string{"list", "show", "which", "what", "who"}
+	starts   = []string{"start", "begin", "run", "launch", "kick"}
+	absences = []string{"absent", "absence", "absences", "away", "off", "vacation", "vacations", "holiday", "holidays"}
+)
+
+// rules are checked in order, the first one matching is the intent, so the most specific ones go first
+var rules = []rule{
+	{"daily schedule", [][]string{{"schedule", "reschedule", "move", "set", "change"}, daily}, scheduleArgs},
+	{"daily add all", [][]string{{"add", "invite", "include"}, {"all", "everyone", "everybody"}}, nil},
+	{"daily add member", [][]string{{"add", "invite", "include"}, append(daily, members...)}, mentionArgs},
+	{"daily delete member", [][]string{{"remove", "delete", "drop", "exclude"}, append(daily, members...)}, mentionArgs},
+	{"daily skip", [][]string{{"skip", "jump"}, {memberWord}}, mentionArgs},
+	{"daily snooze", [][]string{{"snooze", "pause", "postpone", "delay"}, append(daily, durationWord)}, durationArgs},
+	{"daily stop", [][]string{{"stop", "abort", "cancel"}, daily}, nil},
+	{"daily end", [][]string{{"end", "finish", "close", "wrap"}, daily}, nil},
+	{"daily start", [][]string{starts, daily}, nil},
+	{"daily resume", [][]string{{"resume", "continue", "late"}, append(daily, "report")}, nil},
+	{"daily pending", [][]string{{"pending", "missing", "owe", "owes", "left", "waiting"},
+		append(daily, "report", "reports")}, nil},
+	{"daily mood report", [][]string{{"mood", "moods", "feeling", "feelings"}, {"report", "chart", "week"}}, nil},
+	{"daily mood", [][]string{{"mood", "feel", "feeling", "feelings"}}, nil},
+	{"daily info", [][]string{{"when", "next", "info", "time"}, daily}, nil},
+	{"daily list members", [][]string{list, append(daily, members...)}, nil},
+	{"meeting start", [][]string{starts, {"meeting", "retro", "retrospective"}}, numberArgs},
+	{"meeting list", [][]string{list, {"meetings", "retros", "retrospectives"}}, nil},
+	{"action done", [][]string{{"done", "finished", "completed", "complete", "close"}, {"action", "actions"}},
+		numberArgs},
+	{"action list", [][]string{append(list, "open"), {"action", "actions"}}, nil},
+	{"remind list", [][]string{list, {"reminders"}}, nil},
+	{"timesheet report", [][]string{{"timesheet", "timesheets"}, {"report", "missing", "who"}}, nil},
+	{"timesheet fill", [][]string{{"timesheet", "timesheets", "hours"}, {"fill", "log", "submit", "send"}}, nil},
+	{"absent list", [][]string{list, absences}, nil},
+	{"morning", [][]string{{"agenda", "today"}, {"agenda", "plan", "what", "show"}}, nil},
+	{"help", [][]string{{"help", "commands", "orders"}}, nil},
+}
+
+// Parse reads the intent of the text typed after the mention of the bot. mentions are the members mentioned in the
+// text as the chat writes them, they are passed to the commands as they are
+func Parse(text string, mentions []string) (Intent, bool) {
+	e := read(text, mentions)
+
+	for _, r := range rules {
+		if !e.matches(r.words) {
+			continue
+		}
+		i := Intent{Command: r.command}
+		if r.args != nil {
+			i.Args = r.args(e)
+		}
+		return i, true
+	}
+	return Intent{}, false
+}
+
+// Suggest returns up to three commands close to the one typed, the most similar first, or none if none of them is
+// close enough
+func Suggest(text string) []string {
+	fields := strings.Fields(strings.ToLower(text))
+
+	type suggestion struct {
+		command  string
+		distance int
+	}
+	var suggestions []suggestion
+	for _, c := range Commands {
+		n := len(strings.Fields(c))
+		if len(fields) < n {
+			continue
+		}
+		// A typo, or a mistake every five letters
+		d := distance(strings.Join(fields[:n], " "), c)
+		if d == 1 || (d > 0 && d*5 <= len(c)) {
+			suggestions = append(suggestions, suggestion{c, d})
+		}
+	}
+
+	// The longest commands first when they are as close, they match more of the text
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return len(suggestions[i].command) > len(suggestions[j].command)
+	})
+
+	var commands []string
+	for i := 0; i < len(suggestions) && i < 3; i++ {
+		commands = append(commands, suggestions[i].command)
+	}
+	return commands
+}
+
+func (e entities) matches(words [][]string) bool {
+	for _, group := range words {
+		found := false
+		for _, w := range group {
+			if e.words[w] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	clockPattern    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	durationPattern = regexp.MustCompile(`^(\d+)(m|min|mins|minute|minutes|h|hr|hrs|hour|hours)?$`)
+	numberPattern   = regexp.MustCompile(`^\d+$`)
+)
+
+// dayKinds are the kinds of words of the days understood, in any language, see i18n.Kind
+var dayKinds = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "weekdays",
+	"everyday"}
+
+// read splits the text in words, the members mentioned are taken out and the days, hours, durations and numbers
+// are read
+func read(text string, mentions []string) entities {
+	e := entities{words: map[string]bool{}}
+
+	for _, m := range mentions {
+		if strings.Contains(text, m) {
+			text = strings.Replace(text, m, " ", -1)
+			e.mentions = append(e.mentions, m)
+		}
+	}
+	if len(e.mentions) > 0 {
+		e.words[memberWord] = true
+	}
+
+	text = strings.Replace(strings.ToLower(text), "every day", "everyday", -1)
+	tokens := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ':' && r != '-' && r != '\''
+	})
+	for i := range tokens {
+		tokens[i] = strings.TrimSuffix(strings.TrimSuffix(strings.Trim(tokens[i], ":-'"), "'s"), "'")
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		if kind := i18n.Kind(t, dayKinds...); kind != "" {
+			e.days = appendDay(e.days, kind)
+			e.words[dayWord] = true
+		}
+
+		// An hour is 9:30, 9am or 9 pm, or any number after at
+		if m := clockPattern.FindStringSubmatch(t); m != nil && e.time == "" {
+			suffix := m[3]
+			if suffix == "" && (next == "am" || next == "pm") {
+				suffix = next
+			}
+			if m[2] != "" || suffix != "" || (i > 0 && tokens[i-1] == "at") {
+				if clock, ok := readClock(m[1], m[2], suffix); ok {
+					e.time = clock
+					e.words[timeWord] = true
+					if suffix != m[3] {
+						i++
+					}
+					continue
+				}
+			}
+		}
+
+		// A duration is 15m, 15 minutes or an hour
+		if m := durationPattern.FindStringSubmatch(t); m != nil && e.duration == "" {
+			unit := m[2]
+			if unit == "" && durationPattern.MatchString("1"+next) && !numberPattern.MatchString(next) {
+				unit = next
+				i++
+			}
+			if unit != "" {
+				e.duration = m[1] + unit[:1]
+				e.words[durationWord] = true
+				continue
+			}
+		}
+		if (t == "a" || t == "an") && durationPattern.MatchString("1"+next) && e.duration == "" {
+			e.duration = "1" + next[:1]
+			e.words[durationWord] = true
+			i++
+			continue
+		}
+
+		if numberPattern.MatchString(t) && e.number == "" {
+			e.number = t
+			e.words[numberWord] = true
+			continue
+		}
+
+		e.words[t] = true
+	}
+
+	return e
+}
+
+// readClock returns the hour in 24 hours format, e.g. 21:30
+func readClock(hour, minute, suffix string) (string, bool) {
+	h, _ := strconv.Atoi(hour)
+	mm := 0
+	if minute != "" {
+		mm, _ = strconv.Atoi(minute)
+	}
+
+	switch suffix {
+	case "am":
+		if h < 1 || h > 12 {
+			return "", false
+		}
+		if h == 12 {
+			h = 0
+		}
+	case "pm":
+		if h < 1 || h > 12 {
+			return "", false
+		}
+		if h != 12 {
+			h += 12
+		}
+	}
+	if h > 23 || mm > 59 {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%02d", h, mm), true
+}
+
+// appendDay adds the day once, weekdays and everyday replace the days read before
+func appendDay(days []string, kind string) []string {
+	switch kind {
+	case "weekdays":
+		return []string{"weekdays"}
+	case "everyday":
+		return []string{"day"}
+	}
+	for _, d := range days {
+		if d == kind || d == "weekdays" || d == "day" {
+			return days
+		}
+	}
+	return append(days, kind)
+}
+
+// scheduleArgs returns the days and the hour of the schedule, weekdays if only the hour is typed. Without hour
+// there are no arguments, so the schedule is asked
+func scheduleArgs(e entities) []string {
+	if e.time == "" {
+		return nil
+	}
+	days := "weekdays"
+	if len(e.days) > 0 {
+		days = strings.Join(e.days, ",")
+	}
+	return []string{days, e.time}
+}
+
+func mentionArgs(e entities) []string {
+	return e.mentions
+}
+
+func durationArgs(e entities) []string {
+	if e.duration == "" {
+		return nil
+	}
+	return []string{e.duration}
+}
+
+func numberArgs(e entities) []string {
+	if e.number == "" {
+		return nil
+	}
+	return []string{e.number}
+}
+
+// distance is the number of letters to add, remove, change or swap with the next one to get b from a
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = smallest(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = smallest(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func smallest(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,121 @@
+package intent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text     string
+		mentions []string
+		command  string
+		args     []string
+	}{
+		{"schedule the daily at 9:30 on weekdays", nil, "daily schedule", []string{"weekdays", "9:30"}},
+		{"move the standup to monday and thursday at 10am", nil, "daily schedule", []string{"monday,thursday", "10:00"}},
+		{"set the daily every day at 9 pm", nil, "daily schedule", []string{"day", "21:00"}},
+		{"reschedule the daily", nil, "daily schedule", nil},
+		{"move the daily to lunes at 9:00", nil, "daily schedule", []string{"monday", "9:00"}},
+		{"add <@U2> to the daily", []string{"<@U2>"}, "daily add member", []string{"<@U2>"}},
+		{"invite everyone", nil, "daily add all", nil},
+		{"drop <@U2> from the standup", []string{"<@U2>"}, "daily delete member", []string{"<@U2>"}},
+		{"skip <@U2>", []string{"<@U2>"}, "daily skip", []string{"<@U2>"}},
+		{"snooze the daily 15 minutes", nil, "daily snooze", []string{"15m"}},
+		{"pause for an hour", nil, "daily snooze", []string{"1h"}},
+		{"please postpone the standup", nil, "daily snooze", nil},
+		{"cancel today's daily", nil, "daily stop", nil},
+		{"wrap up the daily", nil, "daily end", nil},
+		{"let's start the standup", nil, "daily start", nil},
+		{"I want to resume my daily report", nil, "daily resume", nil},
+		{"who is missing the daily report?", nil, "daily pending", nil},
+		{"how is the mood this week?", nil, "daily mood report", nil},
+		{"when is the next daily?", nil, "daily info", nil},
+		{"who is in the team?", nil, "daily list members", nil},
+		{"start retro 2", nil, "meeting start", []string{"2"}},
+		{"action 3 is done", nil, "action done", []string{"3"}},
+		{"show the open actions", nil, "action list", nil},
+		{"list the reminders", nil, "remind list", nil},
+		{"I want to fill my timesheet", nil, "timesheet fill", nil},
+		{"who is on vacation?", nil, "absent list", nil},
+		{"what is the agenda?", nil, "morning", nil},
+		{"what's the plan for today?", nil, "morning", nil},
+		{"which commands do you know?", nil, "help", nil},
+	}
+
+	for _, tt := range tests {
+		i, ok := Parse(tt.text, tt.mentions)
+		if !ok || i.Command != tt.command || !reflect.DeepEqual(i.Args, tt.args) {
+			t.Errorf("Parse(%q) = %q %v, want %q %v", tt.text, i.Command, i.Args, tt.command, tt.args)
+		}
+	}
+}
+
+func TestParseUnknown(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"good morning!",
+		"thanks a lot",
+		"I'll skip lunch today",
+		"drop it",
+		"cancel my subscription",
+		"the end is near",
+		"who ate my sandwich?",
+		"remove the bug before friday",
+	} {
+		if i, ok := Parse(text, nil); ok {
+			t.Errorf("Parse(%q) = %q, want no intent", text, i)
+		}
+	}
+}
+
+func TestIsDestructive(t *testing.T) {
+	tests := []struct {
+		text        string
+		mentions    []string
+		destructive bool
+	}{
+		{"drop <@U2> from the standup", []string{"<@U2>"}, true},
+		{"cancel today's daily", nil, true},
+		{"wrap up the daily", nil, true},
+		{"skip <@U2>", []string{"<@U2>"}, true},
+		{"add <@U2> to the daily", []string{"<@U2>"}, false},
+		{"let's start the standup", nil, false},
+		{"schedule the daily at 9:30", nil, false},
+	}
+
+	for _, tt := range tests {
+		i, ok := Parse(tt.text, tt.mentions)
+		if !ok {
+			t.Errorf("Parse(%q) didn't understand it", tt.text)
+			continue
+		}
+		if i.IsDestructive() != tt.destructive {
+			t.Errorf("Parse(%q).IsDestructive() = %v, want %v", tt.text, i.IsDestructive(), tt.destructive)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		text  string
+		first string
+	}{
+		{"daily strat", "daily start"},
+		{"daly info", "daily info"},
+		{"remind lsit", "remind list"},
+		{"timesheet reprot", "timesheet report"},
+	}
+
+	for _, tt := range tests {
+		if s := Suggest(tt.text); len(s) == 0 || s[0] != tt.first {
+			t.Errorf("Suggest(%q) = %v, want %q first", tt.text, s, tt.first)
+		}
+	}
+
+	for _, text := range []string{"hello there", "what a nice day", "daily start"} {
+		if s := Suggest(text); len(s) > 0 {
+			t.Errorf("Suggest(%q) = %v, want none", text, s)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	rruleTimeLayout = "20060102T150405"
+
+	// maxPeriods limits the search of rules which never happen, like the 30th of February
+	maxPeriods = 10000
+)
+
+var rruleWeekdays = map[string]time.Weekday{"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday,
+	"WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday}
+
+// weekdayNum is a BYDAY value, like MO or 1MO (the first Monday of the month) and -1FR (the last Friday)
+type weekdayNum struct {
+	n       int
+	weekday time.Weekday
+}
+
+// rrule is the subset of RFC 5545 recurrence rules useful for meetings: daily, weekly and monthly frequencies
+// with INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYHOUR, BYMINUTE and BYSETPOS
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []int
+	byHour     []int
+	byMinute   []int
+	bySetPos   []int
+	dtstart    time.Time
+}
+
+func isRRule(expr string) bool {
+	upper := strings.ToUpper(expr)
+	return strings.Contains(upper, "RRULE:") || strings.Contains(upper, "FREQ=")
+}
+
+func parseRRule(expr string, loc *time.Location) (Schedule, error) {
+	r := &rrule{interval: 1}
+	hasStart, hasTime := false, false
+
+	for _, line := range strings.Fields(expr) {
+		upper := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(upper, "DTSTART"):
+			i := strings.LastIndex(line, ":")
+			if i < 0 {
+				return nil, fmt.Errorf("scheduler: invalid %s", line)
+			}
+			startLoc := loc
+			if j := strings.Index(upper, "TZID="); j >= 0 && j < i {
+				var err error
+				if startLoc, err = time.LoadLocation(line[j+len("TZID=") : i]); err != nil {
+					return nil, fmt.Errorf("scheduler: %v", err)
+				}
+			}
+			start, err := parseRRuleTime(line[i+1:], startLoc)
+			if err != nil {
+				return nil, err
+			}
+			r.dtstart = start
+			hasStart = true
+			hasTime = len(line)-i-1 > len("20060102")
+		default:
+			if err := r.parseRule(strings.TrimPrefix(upper, "RRULE:")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.freq == "" {
+		return nil, fmt.Errorf("scheduler: FREQ is required in %q", expr)
+	}
+
+	// A rule without time would run at midnight
+	if len(r.byHour) == 0 && !hasTime {
+		return nil, fmt.Errorf("scheduler: add BYHOUR, or the time of DTSTART, to %q", expr)
+	}
+	if !hasStart {
+		if r.count > 0 {
+			return nil, fmt.Errorf("scheduler: add DTSTART to %q", expr)
+		}
+		r.dtstart = time.Date(1970, 1, 1, 0, 0, 0, 0, loc)
+	}
+	if len(r.byHour) == 0 {
+		r.byHour = []int{r.dtstart.Hour()}
+	}
+	if len(r.byMinute) == 0 {
+		r.byMinute = []int{r.dtstart.Minute()}
+	}
+
+	return r, nil
+}
+
+func parseRRuleTime(s string, loc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(s, "Z") {
+		return time.ParseInLocation(rruleTimeLayout, strings.TrimSuffix(s, "Z"), time.UTC)
+	}
+	if len(s) == len("20060102") {
+		return time.ParseInLocation("20060102", s, loc)
+	}
+	t, err := time.ParseInLocation(rruleTimeLayout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("scheduler: invalid date %q", s)
+	}
+	return t, nil
+}
+
+func (r *rrule) parseRule(rule string) error {
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("scheduler: invalid rule part %q", part)
+		}
+
+		var err error
+		switch kv[0] {
+		case "FREQ":
+			if kv[1] != "DAILY" && kv[1] != "WEEKLY" && kv[1] != "MONTHLY" {
+				return fmt.Errorf("scheduler: FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+			r.freq = kv[1]
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(kv[1]); err != nil || r.interval <= 0 {
+				return fmt.Errorf("scheduler: invalid INTERVAL %q", kv[1])
+			}
+		case "COUNT":
+			if r.count, err = strconv.Atoi(kv[1]); err != nil || r.count <= 0 {
+				return fmt.Errorf("scheduler: invalid COUNT %q", kv[1])
+			}
+		case "UNTIL":
+			if r.until, err = parseRRuleTime(kv[1], time.Local); err != nil {
+				return err
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(kv[1], ",") {
+				if len(d) < 2 {
+					return fmt.Errorf("scheduler: invalid BYDAY %q", d)
+				}
+				w, ok := rruleWeekdays[d[len(d)-2:]]
+				if !ok {
+					return fmt.Errorf("scheduler: invalid BYDAY %q", d)
+				}
+				n := 0
+				if len(d) > 2 {
+					if n, err = strconv.Atoi(d[:len(d)-2]); err != nil || n == 0 || n < -5 || n > 5 {
+						return fmt.Errorf("scheduler: invalid BYDAY %q", d)
+					}
+				}
+				r.byDay = append(r.byDay, weekdayNum{n, w})
+			}
+		case "BYMONTHDAY":
+			if r.byMonthDay, err = parseInts(kv[1], -31, 31); err != nil {
+				return err
+			}
+		case "BYMONTH":
+			if r.byMonth, err = parseInts(kv[1], 1, 12); err != nil {
+				return err
+			}
+		case "BYHOUR":
+			if r.byHour, err = parseInts(kv[1], 0, 23); err != nil {
+				return err
+			}
+		case "BYMINUTE":
+			if r.byMinute, err = parseInts(kv[1], 0, 59); err != nil {
+				return err
+			}
+		case "BYSETPOS":
+			if r.bySetPos, err = parseInts(kv[1], -366, 366); err != nil {
+				return err
+			}
+		case "WKST":
+			// Weeks always start on Monday
+		default:
+			return fmt.Errorf("scheduler: %s isn't supported", kv[0])
+		}
+	}
+
+	return nil
+}
+
+func parseInts(s string, min, max int) ([]int, error) {
+	var values []int
+	for _, v := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(v)
+		if err != nil || i == 0 && min < 0 || i < min || i > max {
+			return nil, fmt.Errorf("scheduler: %q must be between %d and %d", v, min, max)
+		}
+		values = append(values, i)
+	}
+	return values, nil
+}
+
+// Next walks the periods (days, weeks or months) from the one containing t, or from DTSTART if COUNT is used
+func (r *rrule) Next(t time.Time) time.Time {
+	first := 0
+	if r.count == 0 && t.After(r.dtstart) {
+		first = r.periodIndex(t.In(r.dtstart.Location()))
+	}
+
+	n := 0
+	for i := first; i < first+maxPeriods; i++ {
+		start := r.periodStart(i)
+		if !r.until.IsZero() && start.After(r.until) {
+			return time.Time{}
+		}
+
+		for _, o := range r.occurrences(start) {
+			if o.Before(r.dtstart) || !r.until.IsZero() && o.After(r.until) {
+				continue
+			}
+			n++
+			if r.count > 0 && n > r.count {
+				return time.Time{}
+			}
+			if o.After(t) {
+				return o
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// periodIndex returns the period containing t, counted in intervals from DTSTART
+func (r *rrule) periodIndex(t time.Time) int {
+	s := r.dtstart
+	var units int
+	switch r.freq {
+	case "DAILY":
+		units = daysBetween(s, t)
+	case "WEEKLY":
+		units = daysBetween(weekStart(s), t) / 7
+	case "MONTHLY":
+		units = (t.Year()-s.Year())*12 + int(t.Month()-s.Month())
+	}
+	return units / r.interval
+}
+
+func (r *rrule) periodStart(i int) time.Time {
+	s := r.dtstart
+	day := time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, s.Location())
+	switch r.freq {
+	case "WEEKLY":
+		return weekStart(s).AddDate(0, 0, 7*i*r.interval)
+	case "MONTHLY":
+		return time.Date(s.Year(), s.Month()+time.Month(i*r.interval), 1, 0, 0, 0, 0, s.Location())
+	default:
+		return day.AddDate(0, 0, i*r.interval)
+	}
+}
+
+// occurrences returns the sorted occurrences of the period, after applying BYSETPOS
+func (r *rrule) occurrences(start time.Time) []time.Time {
+	var days []time.Time
+	switch r.freq {
+	case "DAILY":
+		days = []time.Time{start}
+	case "WEEKLY":
+		for d := 0; d < 7; d++ {
+			days = append(days, start.AddDate(0, 0, d))
+		}
+	case "MONTHLY":
+		for d := start; d.Month() == start.Month(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	}
+
+	var result []time.Time
+	for _, d := range days {
+		if !r.matchDay(d) {
+			continue
+		}
+		for _, h := range r.byHour {
+			for _, m := range r.byMinute {
+				result = append(result, time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, d.Location()))
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+
+	if len(r.bySetPos) == 0 {
+		return result
+	}
+
+	var selected []time.Time
+	for _, p := range r.bySetPos {
+		switch {
+		case p > 0 && p <= len(result):
+			selected = append(selected, result[p-1])
+		case p < 0 && -p <= len(result):
+			selected = append(selected, result[len(result)+p])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Before(selected[j]) })
+	return selected
+}
+
+func (r *rrule) matchDay(d time.Time) bool {
+	if len(r.byMonth) > 0 && !containsInt(r.byMonth, int(d.Month())) {
+		return false
+	}
+
+	lastDay := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, d.Location()).Day()
+
+	if len(r.byMonthDay) > 0 {
+		found := false
+		for _, md := range r.byMonthDay {
+			if md == d.Day() || md < 0 && lastDay+md+1 == d.Day() {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(r.byDay) > 0 {
+		found := false
+		for _, wd := range r.byDay {
+			if wd.weekday != d.Weekday() {
+				continue
+			}
+			// Ordinals only count in monthly rules: 1MO is the first Monday, -1MO the last one
+			if wd.n == 0 || r.freq != "MONTHLY" ||
+				wd.n > 0 && (d.Day()-1)/7+1 == wd.n ||
+				wd.n < 0 && (lastDay-d.Day())/7+1 == -wd.n {
+				found = true
+			}
+		}
+		return found
+	}
+
+	// Without BYxxx rules, the day of DTSTART is repeated
+	if len(r.byMonthDay) == 0 {
+		switch r.freq {
+		case "WEEKLY":
+			return d.Weekday() == r.dtstart.Weekday()
+		case "MONTHLY":
+			return d.Day() == r.dtstart.Day()
+		}
+	}
+
+	return true
+}
+
+func containsInt(values []int, v int) bool {
+	for _, i := range values {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// weekStart returns the Monday of the week of t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween counts calendar days, ignoring daylight saving changes
+func daysBetween(from, to time.Time) int {
+	f := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	t := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(t.Sub(f).Hours() / 24)
+}
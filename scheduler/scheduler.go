@@ -0,0 +1,121 @@
+// Package scheduler computes when the meetings of leanmanager must run
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+// ErrNotScheduled is returned when the meeting hasn't a schedule yet
+var ErrNotScheduled = errors.New("scheduler: meeting not scheduled")
+
+// everyDays are the days understood by Every, as cron days of the week
+var everyDays = map[string]string{
+	"sunday": "0", "sun": "0",
+	"monday": "1", "mon": "1",
+	"tuesday": "2", "tue": "2",
+	"wednesday": "3", "wed": "3",
+	"thursday": "4", "thu": "4", "thur": "4",
+	"friday": "5", "fri": "5",
+	"saturday": "6", "sat": "6",
+	"day": "*", "everyday": "*", "daily": "*", "weekday": "1-5", "weekend": "0,6",
+}
+
+// Schedule returns the occurrences of a meeting
+type Schedule interface {
+	// Next returns the first occurrence after t, or the zero time if there aren't more occurrences
+	Next(t time.Time) time.Time
+}
+
+// Parse reads a cron expression (`0 9 * * 1-5`) or a RFC 5545 recurrence rule
+// (`DTSTART:20170102T090000 RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO`). Times without zone are local.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, ErrNotScheduled
+	}
+
+	if isRRule(expr) {
+		return parseRRule(expr, time.Local)
+	}
+	return parseCron(expr)
+}
+
+// WithStart anchors a recurrence rule without DTSTART to the day of start, so intervals like
+// `every other Monday` count from there. The time comes from BYHOUR, the rule is invalid without it. Cron
+// expressions are returned as they are.
+func WithStart(expr string, start time.Time) string {
+	expr = strings.TrimSpace(expr)
+	if !isRRule(expr) || strings.Contains(strings.ToUpper(expr), "DTSTART") {
+		return expr
+	}
+
+	if !strings.HasPrefix(strings.ToUpper(expr), "RRULE:") {
+		expr = "RRULE:" + expr
+	}
+	return "DTSTART;VALUE=DATE:" + start.Format("20060102") + " " + expr
+}
+
+// Every reads a schedule typed like `friday 16:00`, `weekdays 9:00`, `everyday 8:30` or `monday,thursday 10:30` and
+// returns it as a cron expression. Other cron expressions and recurrence rules are returned anchored to start, see
+// WithStart
+func Every(text string, start time.Time) (string, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 2 {
+		if t, err := time.Parse("15:04", fields[1]); err == nil {
+			var dow []string
+			for _, d := range strings.Split(fields[0], ",") {
+				v, ok := everyDays[strings.TrimSuffix(d, "s")]
+				if !ok {
+					dow = nil
+					break
+				}
+				dow = append(dow, v)
+			}
+			if dow != nil {
+				expr := fmt.Sprintf("%d %d * * %s", t.Minute(), t.Hour(), strings.Join(dow, ","))
+				_, err := parseCron(expr)
+				return expr, err
+			}
+		}
+	}
+
+	expr := WithStart(text, start)
+	_, err := Parse(expr)
+	return expr, err
+}
+
+// FromDays builds the weekly schedule of the days and hour selected in `daily schedule`
+func FromDays(days []time.Weekday, startTime time.Time) (Schedule, error) {
+	if len(days) == 0 {
+		return nil, ErrNotScheduled
+	}
+
+	dow := make([]string, len(days))
+	for i, d := range days {
+		dow[i] = fmt.Sprint(int(d))
+	}
+	return parseCron(fmt.Sprintf("%d %d * * %s", startTime.Minute(), startTime.Hour(), strings.Join(dow, ",")))
+}
+
+// ForDaily returns the schedule of the Daily Meeting, the expression if there is one or the days otherwise
+func ForDaily(d api.DailyMeeting) (Schedule, error) {
+	if d.Schedule != "" {
+		return Parse(d.Schedule)
+	}
+	return FromDays(d.Days, d.StartTime)
+}
+
+// Pending returns the last occurrence after since and not after now, or the zero time if there isn't any. It's
+// used to run each occurrence only once
+func Pending(s Schedule, since, now time.Time) time.Time {
+	var last time.Time
+	for o := s.Next(since); !o.IsZero() && !o.After(now); o = s.Next(o) {
+		last = o
+	}
+	return last
+}
@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// from is Monday, 19 Oct 2026 at 10:00
+var from = time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"30 9 * * 1-5", at(10, 20, 9, 30)},
+		{"0 10 * * 1", at(10, 26, 10, 0)},
+		{"*/15 * * * *", at(10, 19, 10, 15)},
+		{"0 9 1 * *", at(11, 1, 9, 0)},
+		{"0 9 13 * 5", at(10, 23, 9, 0)},
+		{"0 9 * * sun", at(10, 25, 9, 0)},
+		{"0 9 * * 7", at(10, 25, 9, 0)},
+		{"0 9 * nov mon", at(11, 2, 9, 0)},
+		{"@weekly", at(10, 25, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if next := s.Next(from); !next.Equal(tt.next) {
+			t.Errorf("Parse(%q).Next = %v, want %v", tt.expr, next, tt.next)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"0 9 * *",
+		"60 9 * * *",
+		"0 24 * * *",
+		"0 9 * * 8",
+		"0 9 * * fri-mon",
+		"*/0 * * * *",
+		"CRON_TZ=Nowhere/City 0 9 * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) didn't fail", expr)
+		}
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"FREQ=WEEKLY;BYDAY=MO,WE;BYHOUR=9;BYMINUTE=30", at(10, 21, 9, 30)},
+		{"DTSTART:20261005T093000 RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", at(11, 2, 9, 30)},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=1;BYHOUR=9", at(11, 2, 9, 0)},
+		{"FREQ=MONTHLY;BYDAY=-1FR;BYHOUR=15", at(10, 30, 15, 0)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;BYHOUR=17", at(10, 31, 17, 0)},
+		{"DTSTART:20261019T080000 RRULE:FREQ=DAILY;COUNT=2", at(10, 20, 8, 0)},
+		{"DTSTART:20261019T080000 RRULE:FREQ=DAILY;COUNT=1", time.Time{}},
+		{"DTSTART:20261001T090000 RRULE:FREQ=DAILY;UNTIL=20261019T235959", time.Time{}},
+		{"DTSTART;VALUE=DATE:20261019 RRULE:FREQ=DAILY;BYHOUR=11", at(10, 19, 11, 0)},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if next := s.Next(from); !next.Equal(tt.next) {
+			t.Errorf("Parse(%q).Next = %v, want %v", tt.expr, next, tt.next)
+		}
+	}
+}
+
+func TestParseRRuleInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"FREQ=WEEKLY;BYDAY=MO",
+		"DTSTART;VALUE=DATE:20261019 RRULE:FREQ=WEEKLY",
+		"RRULE:BYHOUR=9",
+		"FREQ=YEARLY;BYHOUR=9",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=DAILY;INTERVAL=0;BYHOUR=9",
+		"FREQ=DAILY;COUNT=3;BYHOUR=9",
+		"FREQ=WEEKLY;BYDAY=XX;BYHOUR=9",
+		"FREQ=WEEKLY;BYWEEKNO=2;BYHOUR=9",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) didn't fail", expr)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	tests := []struct {
+		text string
+		expr string
+	}{
+		{"friday 16:00", "0 16 * * 5"},
+		{"weekdays 9:00", "0 9 * * 1-5"},
+		{"everyday 9:00", "0 9 * * *"},
+		{"day 9:00", "0 9 * * *"},
+		{"weekend 11:00", "0 11 * * 0,6"},
+		{"Monday,Thursday 10:30", "30 10 * * 1,4"},
+		{"mondays 8:05", "5 8 * * 1"},
+		{"30 9 * * 1-5", "30 9 * * 1-5"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;BYHOUR=9",
+			"DTSTART;VALUE=DATE:20261019 RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;BYHOUR=9"},
+		{"DTSTART:20261005T093000 RRULE:FREQ=WEEKLY", "DTSTART:20261005T093000 RRULE:FREQ=WEEKLY"},
+	}
+
+	for _, tt := range tests {
+		expr, err := Every(tt.text, from)
+		if err != nil {
+			t.Errorf("Every(%q) error: %v", tt.text, err)
+			continue
+		}
+		if expr != tt.expr {
+			t.Errorf("Every(%q) = %q, want %q", tt.text, expr, tt.expr)
+		}
+	}
+}
+
+func TestEveryInvalid(t *testing.T) {
+	for _, text := range []string{
+		"FREQ=WEEKLY;BYDAY=MO",
+		"someday 9:00",
+		"friday 25:00",
+		"friday",
+		"",
+	} {
+		if expr, err := Every(text, from); err == nil {
+			t.Errorf("Every(%q) = %q, want an error", text, expr)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+// absenceDayLayout is how the days of the absences are typed
+const absenceDayLayout = "2006-01-02"
+
+func manageAddAbsence(chat ChatAdapter, m *Message) {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager absent me 2026-10-20` or "+
+			"`@leanmanager absent @member 2026-10-20 2026-10-23` for several days"),
+	}
+
+	a := m.getValidAbsence(chat)
+	if a == nil {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	if a.MemberID != m.User && !isAdmin(m.getChannelID(), m.User) {
+		message.Text = tr(m.getChannelID(), ":no_entry: Only admins can register the absences of other members")
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	if err := addAbsence(a); err != nil {
+		log.Printf("slackutils: API Server is failing adding absence to channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! Absence `%d` of %s %s :palm_tree:", a.ID, chat.Mention(a.MemberID),
+		formatAbsenceDays(m.getChannelID(), *a))
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageListAbsences(chat ChatAdapter, m *Message) {
+	absences, err := listAbsences(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve absences of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), "There are no absences, type `@leanmanager absent me 2026-10-20` to add one"),
+	}
+
+	// The past absences are of no interest anymore
+	today := time.Now().Format(absenceDayLayout)
+	var b bytes.Buffer
+	for _, a := range absences {
+		if a.To.Format(absenceDayLayout) < today {
+			continue
+		}
+		b.WriteString("\n• `" + strconv.Itoa(a.ID) + "` " + chat.Mention(a.MemberID) + " " +
+			formatAbsenceDays(m.getChannelID(), a))
+	}
+	if b.Len() > 0 {
+		message.Text = tr(m.getChannelID(), "Absences of the channel:") + b.String()
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageDeleteAbsence(chat ChatAdapter, m *Message) {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), ":scream: Type something like `@leanmanager absent delete 3`, see `absent list`"),
+	}
+
+	absences, err := listAbsences(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve absences of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	fields := strings.Fields(m.getAbsenceArgs(" delete"))
+	if len(fields) > 0 {
+		id, _ := strconv.Atoi(strings.Trim(fields[0], "`"))
+		for _, a := range absences {
+			if a.ID != id {
+				continue
+			}
+			if a.MemberID != m.User && !isAdmin(m.getChannelID(), m.User) {
+				message.Text = tr(m.getChannelID(), ":no_entry: Only the absent member or an admin can delete the absence")
+			} else if err := delAbsence(m.getChannelID(), id); err != nil {
+				log.Printf("slackutils: error deleting absence in channel %s: %v", m.getChannelID(), err)
+			} else {
+				message.Text = tr(m.getChannelID(), "Done! Absence `%d` deleted", id)
+			}
+		}
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// formatAbsenceDays tells the days the member won't be working, in the language of the channel
+func formatAbsenceDays(channelID string, a api.Absence) string {
+	if a.From.Format(absenceDayLayout) == a.To.Format(absenceDayLayout) {
+		return tr(channelID, "on %s", formatDay(channelID, a.From))
+	}
+	return tr(channelID, "from %s to %s", formatDay(channelID, a.From), formatDay(channelID, a.To))
+}
+
+// getValidAbsence reads `absent <@member|me> <first day> [last day]`, it returns nil if it's not valid
+func (m Message) getValidAbsence(chat ChatAdapter) *api.Absence {
+	fields := strings.Fields(m.getAbsenceArgs(""))
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil
+	}
+
+	a := &api.Absence{ChannelID: m.getChannelID()}
+	if strings.EqualFold(fields[0], "me") {
+		a.MemberID = m.User
+	} else if users := chat.ParseMentions(fields[0]); len(users) > 0 {
+		a.MemberID = users[0]
+	} else {
+		return nil
+	}
+
+	var err error
+	if a.From, err = time.ParseInLocation(absenceDayLayout, fields[1], time.Local); err != nil {
+		return nil
+	}
+	a.To = a.From
+	if len(fields) == 3 {
+		if a.To, err = time.ParseInLocation(absenceDayLayout, fields[2], time.Local); err != nil {
+			return nil
+		}
+	}
+	if a.To.Before(a.From) || a.To.Format(absenceDayLayout) < time.Now().Format(absenceDayLayout) {
+		return nil
+	}
+
+	return a
+}
+
+// getAbsenceArgs returns the text typed after `absent<command>`
+func (m Message) getAbsenceArgs(command string) string {
+	i := strings.Index(m.Text, "absent"+command)
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(m.Text[i+len("absent"+command):])
+}
+
+func (m Message) isAddAbsenceMsj(botMention string) bool {
+	return m.isAbsenceMsj(botMention, " ")
+}
+
+func (m Message) isListAbsencesMsj(botMention string) bool {
+	return m.isAbsenceMsj(botMention, " list")
+}
+
+func (m Message) isDeleteAbsenceMsj(botMention string) bool {
+	return m.isAbsenceMsj(botMention, " delete")
+}
+
+func (m Message) isAbsenceMsj(botMention, command string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" absent"+command) ||
+		strings.HasPrefix(m.Text, "leanmanager absent"+command)) {
+		return true
+	}
+
+	return false
+}
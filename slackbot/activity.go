@@ -0,0 +1,101 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+const (
+	// activeWindow is how long a message or a presence change says if a member is online
+	activeWindow = 30 * time.Minute
+
+	// defaultActiveShare is the percentage of members online needed to start a flexible Daily Meeting
+	defaultActiveShare = 50
+)
+
+type memberActivity struct {
+	active bool
+	seen   time.Time
+}
+
+// activityController tracks which users are online, from their messages and presence changes
+type activityController struct {
+	sync.Mutex
+	a map[string]memberActivity
+}
+
+var activity = activityController{a: make(map[string]memberActivity)}
+
+// observe records the activity of the users in the messages received from the chat
+func (ac *activityController) observe(m Message) {
+	switch m.Type {
+	case "message":
+		if m.User != "" {
+			ac.set(m.User, true)
+		}
+	case "presence_change":
+		active := m.Presence == "active"
+		if m.User != "" {
+			ac.set(m.User, active)
+		}
+		for _, u := range m.Users {
+			ac.set(u, active)
+		}
+	}
+}
+
+func (ac *activityController) set(userID string, active bool) {
+	ac.Lock()
+	ac.a[userID] = memberActivity{active: active, seen: time.Now()}
+	ac.Unlock()
+}
+
+// isActive uses the last activity seen, asking the chat for the presence when it's too old
+func (ac *activityController) isActive(chat ChatAdapter, userID string) bool {
+	ac.Lock()
+	act, ok := ac.a[userID]
+	ac.Unlock()
+
+	if ok && time.Since(act.seen) < activeWindow {
+		return act.active
+	}
+
+	away, err := chat.IsAway(userID)
+	if err != nil {
+		log.Printf("slackbot: error retrieving presence of %s: %v", userID, err)
+		return false
+	}
+	ac.set(userID, !away)
+	return !away
+}
+
+// isTeamActive checks if enough members of the Daily Meeting are online to start it
+func isTeamActive(chat ChatAdapter, d api.DailyMeeting) bool {
+	teamMembers, err := listTeamMembers(d.ChannelID)
+	if err != nil {
+		log.Printf("slackbot: error invoking API Server to retrieve members of channel: %v", err)
+		return false
+	}
+
+	share := d.ActiveShare
+	if share <= 0 {
+		share = defaultActiveShare
+	}
+
+	total, active := 0, 0
+	for _, tm := range teamMembers {
+		if tm.IsObserver() {
+			continue
+		}
+		total++
+		if activity.isActive(chat, tm.ID) {
+			active++
+		}
+	}
+
+	return total == 0 || active*100 >= share*total
+}
@@ -7,15 +7,34 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/antonmry/leanmanager/api"
 )
 
+// apiKeyTransport authenticates all the requests to the API Server with the bot's key
+type apiKeyTransport struct {
+	key string
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "Bearer "+t.key)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
 func storeChannel(c *api.Channel) error {
 
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(&c)
-	resp, err := http.Post(apiserverURL+"/channels",
+	resp, err := apiClient.Post(apiserverURL+"/channels",
 		"application/json", &buf)
 
 	defer resp.Body.Close()
@@ -30,7 +49,7 @@ func storeChannel(c *api.Channel) error {
 func addDailyMeeting(daily *api.DailyMeeting, botID string) error {
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(&daily)
-	resp, err := http.Post(apiserverURL+"/dailymeetings",
+	resp, err := apiClient.Post(apiserverURL+"/dailymeetings",
 		"application/json", &buf)
 
 	defer resp.Body.Close()
@@ -43,9 +62,484 @@ func addDailyMeeting(daily *api.DailyMeeting, botID string) error {
 	return nil
 }
 
+func storeDailyProgress(progress api.DailyProgress) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(progress); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/progress", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store daily progress for channel %s: %v",
+			progress.ChannelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("apiutils: API Server refused daily progress for channel %s: %s", progress.ChannelID, body)
+	}
+
+	return nil
+}
+
+func listDailyProgresses() (progresses []api.DailyProgress, err error) {
+	resp, err := apiClient.Get(apiserverURL + "/progress")
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve dailies in progress: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving dailies in progress, status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&progresses)
+	return progresses, err
+}
+
+func delDailyProgress(channelID string) error {
+	delProgressReq, _ := http.NewRequest("DELETE", apiserverURL+"/progress/"+channelID, nil)
+
+	resp, err := apiClient.Do(delProgressReq)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to delete daily progress in channel %s: %v",
+			channelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("apiutils: no daily in progress in channel %s", channelID)
+	}
+
+	return nil
+}
+
+func storeDailyDigest(digest api.DailyDigest) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(digest); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/digests", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store daily digest for channel %s: %v",
+			digest.ChannelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("apiutils: API Server refused daily digest for channel %s: %s", digest.ChannelID, body)
+	}
+
+	return nil
+}
+
+// getDailyDigest returns the digest of the Daily Meeting of the channel in the day given, nil if there wasn't any
+func getDailyDigest(channelID string, day time.Time) (digest *api.DailyDigest, err error) {
+	resp, err := apiClient.Get(apiserverURL + "/digests/" + channelID + "/" + day.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve daily digest of channel %s: %v",
+			channelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, nil
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving daily digest of channel %s, status %d", channelID,
+			resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&digest)
+	return digest, err
+}
+
+func addDigestAnswers(channelID string, day time.Time, answers []api.DailyAnswer) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(answers); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/digests/"+channelID+"/"+day.Format("2006-01-02")+"/answers",
+		"application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to add answers to the daily digest of channel %s: %v",
+			channelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("apiutils: API Server refused answers for the daily digest of channel %s: %s", channelID,
+			body)
+	}
+
+	return nil
+}
+
+// addMeeting stores the meeting, setting the ID given by the API Server to the new ones
+func addMeeting(meeting *api.Meeting) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(meeting); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/meetings", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store meeting for channel %s: %v",
+			meeting.ChannelID, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 201 {
+		return fmt.Errorf("apiutils: API Server refused meeting for channel %s: %s", meeting.ChannelID, body)
+	}
+
+	return json.Unmarshal(body, meeting)
+}
+
+// listMeetings returns the meetings of the channel, or the ones of all channels if channelID is empty
+func listMeetings(channelID string) (meetings []api.Meeting, err error) {
+	path := "/meetings"
+	if channelID != "" {
+		path += "/" + channelID + "/"
+	}
+
+	resp, err := apiClient.Get(apiserverURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve meetings: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving meetings, status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&meetings)
+	return meetings, err
+}
+
+func delMeeting(channelID, meetingID string) error {
+	delMeetingReq, _ := http.NewRequest("DELETE", apiserverURL+"/meetings/"+channelID+"/"+
+		url.PathEscape(meetingID), nil)
+
+	resp, err := apiClient.Do(delMeetingReq)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to delete meeting %s in channel %s: %v",
+			meetingID, channelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("apiutils: meeting %s not found in channel %s", meetingID, channelID)
+	}
+
+	return nil
+}
+
+// addReminder stores the reminder, setting the ID given by the API Server to the new ones
+func addReminder(reminder *api.Reminder) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(reminder); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/reminders", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store reminder for channel %s: %v",
+			reminder.ChannelID, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 201 {
+		return fmt.Errorf("apiutils: API Server refused reminder for channel %s: %s", reminder.ChannelID, body)
+	}
+
+	return json.Unmarshal(body, reminder)
+}
+
+// listReminders returns the reminders created in the channel, or the ones of all channels if channelID is empty
+func listReminders(channelID string) (reminders []api.Reminder, err error) {
+	path := "/reminders"
+	if channelID != "" {
+		path += "/" + channelID + "/"
+	}
+
+	resp, err := apiClient.Get(apiserverURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve reminders: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving reminders, status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&reminders)
+	return reminders, err
+}
+
+func delReminder(channelID string, reminderID int) error {
+	delReminderReq, _ := http.NewRequest("DELETE", apiserverURL+"/reminders/"+channelID+"/"+
+		strconv.Itoa(reminderID), nil)
+
+	resp, err := apiClient.Do(delReminderReq)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to delete reminder %d in channel %s: %v",
+			reminderID, channelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("apiutils: reminder %d not found in channel %s", reminderID, channelID)
+	}
+
+	return nil
+}
+
+func addTimesheet(timesheet api.Timesheet) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(timesheet); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/timesheets", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store timesheet of %s: %v", timesheet.MemberID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("apiutils: API Server refused timesheet of %s: %s", timesheet.MemberID, body)
+	}
+
+	return nil
+}
+
+// listTimesheets returns the timesheets of the channel in the week, as JSON instead of the CSV export
+func listTimesheets(channelID, week string) (timesheets []api.Timesheet, err error) {
+	req, _ := http.NewRequest("GET", apiserverURL+"/timesheets/"+channelID+"?week="+url.QueryEscape(week), nil)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve timesheets: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving timesheets, status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&timesheets)
+	return timesheets, err
+}
+
+// addAbsence stores the absence, setting the ID given by the API Server to the new ones
+func addAbsence(absence *api.Absence) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(absence); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/absences", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store absence of %s: %v", absence.MemberID, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 201 {
+		return fmt.Errorf("apiutils: API Server refused absence of %s: %s", absence.MemberID, body)
+	}
+
+	return json.Unmarshal(body, absence)
+}
+
+// getTemplate returns the template of the message overridden in the channel, or an empty string if the channel uses
+// the default one
+func getTemplate(channelID, key string) (string, error) {
+	resp, err := apiClient.Get(apiserverURL + "/templates/" + channelID + "/" + key)
+	if err != nil {
+		return "", fmt.Errorf("apiutils: error invoking API Server to retrieve template %s: %v", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("apiutils: error retrieving template %s, status %d", key, resp.StatusCode)
+	}
+
+	var t api.MessageTemplate
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return "", err
+	}
+	if t.Default {
+		return "", nil
+	}
+	return t.Template, nil
+}
+
+func listAbsences(channelID string) (absences []api.Absence, err error) {
+	resp, err := apiClient.Get(apiserverURL + "/absences/" + channelID + "/")
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve absences: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving absences, status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&absences)
+	return absences, err
+}
+
+func delAbsence(channelID string, absenceID int) error {
+	delAbsenceReq, _ := http.NewRequest("DELETE", apiserverURL+"/absences/"+channelID+"/"+
+		strconv.Itoa(absenceID), nil)
+
+	resp, err := apiClient.Do(delAbsenceReq)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to delete absence %d in channel %s: %v",
+			absenceID, channelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("apiutils: absence %d not found in channel %s", absenceID, channelID)
+	}
+
+	return nil
+}
+
+// addRetroEntry stores the entry of a Retrospective, setting the ID given by the API Server to the new ones
+func addRetroEntry(entry *api.RetroEntry) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(entry); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/retros", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store retro entry for channel %s: %v",
+			entry.ChannelID, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 201 {
+		return fmt.Errorf("apiutils: API Server refused retro entry for channel %s: %s", entry.ChannelID, body)
+	}
+
+	return json.Unmarshal(body, entry)
+}
+
+// addActionItem stores the action item, setting the ID given by the API Server to the new ones
+func addActionItem(item *api.ActionItem) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(item); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/actionitems", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store action item for channel %s: %v",
+			item.ChannelID, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 201 {
+		return fmt.Errorf("apiutils: API Server refused action item for channel %s: %s", item.ChannelID, body)
+	}
+
+	return json.Unmarshal(body, item)
+}
+
+func listActionItems(channelID string) (items []api.ActionItem, err error) {
+	resp, err := apiClient.Get(apiserverURL + "/actionitems/" + channelID + "/")
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve action items: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving action items, status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&items)
+	return items, err
+}
+
+func addMood(mood api.Mood) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(mood); err != nil {
+		return err
+	}
+
+	resp, err := apiClient.Post(apiserverURL+"/moods", "application/json", &buf)
+	if err != nil {
+		return fmt.Errorf("apiutils: error invoking API Server to store mood for channel %s: %v",
+			mood.ChannelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("apiutils: API Server refused mood for channel %s: %s", mood.ChannelID, body)
+	}
+
+	return nil
+}
+
+// getMoodTrend returns the average mood of the team in the last days
+func getMoodTrend(channelID string, days int) (trend []api.MoodTrend, err error) {
+	resp, err := apiClient.Get(apiserverURL + "/reports/" + channelID + "/mood?days=" + strconv.Itoa(days))
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve mood trend: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: error retrieving mood trend, status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&trend)
+	return trend, err
+}
+
+func getDailyOrder(channelID string) (order *api.DailyOrder, err error) {
+	resp, err := apiClient.Get(apiserverURL + "/dailymeetings/" + channelID + "/order")
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error invoking API Server to retrieve the order of channel %s: %v",
+			channelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("apiutils: order of the daily meeting of channel %s not found", channelID)
+	}
+
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("apiutils: error parsing API Server response with "+
+			"the order of channel %s: %v", channelID, err)
+	}
+
+	err = json.Unmarshal(buf, &order)
+
+	return order, err
+}
+
 // FIXME: does it should be a pointer instead of a slice?
 func listDailyMeetings(botID string) (teamDailyMeetings []api.DailyMeeting, err error) {
-	resp, err := http.Get(apiserverURL + "/dailymeetings/")
+	resp, err := apiClient.Get(apiserverURL + "/dailymeetings/")
 	defer resp.Body.Close()
 
 	if err != nil || resp.StatusCode != 200 {
@@ -67,7 +561,7 @@ func listDailyMeetings(botID string) (teamDailyMeetings []api.DailyMeeting, err
 func addPredefinedReply(reply *api.PredefinedDailyReply) error {
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(&reply)
-	resp, err := http.Post(apiserverURL+"/replies",
+	resp, err := apiClient.Post(apiserverURL+"/replies",
 		"application/json", &buf)
 
 	defer resp.Body.Close()
@@ -81,7 +575,7 @@ func addPredefinedReply(reply *api.PredefinedDailyReply) error {
 }
 
 func listPredefinedReplies(channelID string) (predefinedReplies *[]api.PredefinedDailyReply, err error) {
-	resp, err := http.Get(apiserverURL + "/replies/" + channelID)
+	resp, err := apiClient.Get(apiserverURL + "/replies/" + channelID)
 	defer resp.Body.Close()
 
 	if err != nil || resp.StatusCode != 200 {
@@ -101,11 +595,9 @@ func listPredefinedReplies(channelID string) (predefinedReplies *[]api.Predefine
 }
 
 func delPredefinedReplies(channelID string) (err error) {
-	clientAPI := &http.Client{}
-
 	delRepliesReq, _ := http.NewRequest("DELETE", apiserverURL+"/replies/"+channelID, nil)
 
-	resp, err := clientAPI.Do(delRepliesReq)
+	resp, err := apiClient.Do(delRepliesReq)
 	defer resp.Body.Close()
 
 	if err != nil || resp.StatusCode != 200 {
@@ -119,7 +611,7 @@ func delPredefinedReplies(channelID string) (err error) {
 func addTeamMember(member *api.Member) error {
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(&member)
-	resp, err := http.Post(apiserverURL+"/members",
+	resp, err := apiClient.Post(apiserverURL+"/members",
 		"application/json", &buf)
 
 	defer resp.Body.Close()
@@ -134,12 +626,10 @@ func addTeamMember(member *api.Member) error {
 }
 
 func delTeamMember(member *api.Member) error {
-	clientAPI := &http.Client{}
-
 	delMemberReq, _ := http.NewRequest("DELETE", apiserverURL+"/members/"+
 		member.ChannelID+"/"+member.ID, nil)
 
-	resp, err := clientAPI.Do(delMemberReq)
+	resp, err := apiClient.Do(delMemberReq)
 	defer resp.Body.Close()
 
 	if err != nil || resp.StatusCode != 200 {
@@ -150,8 +640,30 @@ func delTeamMember(member *api.Member) error {
 	return nil
 }
 
+func getTeamMember(channelID, memberID string) (member *api.Member, err error) {
+	resp, err := apiClient.Get(apiserverURL + "/members/" + channelID + "/" + url.PathEscape(memberID))
+	if err != nil {
+		return nil, fmt.Errorf("slackbot: error invoking API Server to retrieve member %s: %v", memberID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("slackbot: member %s not found in channel %s", memberID, channelID)
+	}
+
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("slackbot: error parsing API Server response with "+
+			"member %s: %v", memberID, err)
+	}
+
+	err = json.Unmarshal(buf, &member)
+
+	return member, err
+}
+
 func listTeamMembers(channelID string) (teamMembers []api.Member, err error) {
-	resp, err := http.Get(apiserverURL + "/members/" + channelID)
+	resp, err := apiClient.Get(apiserverURL + "/members/" + channelID)
 	defer resp.Body.Close()
 
 	if err != nil || resp.StatusCode != 200 {
@@ -0,0 +1,47 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import "errors"
+
+// ErrChatClosed is returned by Receive when the chat won't deliver more messages and the bot must stop
+var ErrChatClosed = errors.New("chat closed")
+
+// ChatAdapter hides the chat platform (Slack, Mattermost...) where the bot is connected
+type ChatAdapter interface {
+	// Connect opens (or reopens) the connection and returns the ID of the bot's user
+	Connect() (botID string, err error)
+	// Receive blocks until the next message or event arrives
+	Receive() (Message, error)
+	// Send posts the message in its channel, or in its thread if ThreadTS is set
+	Send(m Message) error
+	// SendWithAck posts the message and returns the ts the platform has assigned to it
+	SendWithAck(m Message) (ts string, err error)
+	// SendDirect sends a private message to the user
+	SendDirect(userID, text string) error
+	// Mention returns how the user is mentioned in a message, e.g. <@U123> in Slack
+	Mention(userID string) string
+	// MentionChannel returns how everybody in the channel is notified, e.g. <!channel> in Slack
+	MentionChannel() string
+	// ParseMentions returns the IDs of the users mentioned in a text
+	ParseMentions(text string) []string
+	// ParseChannels returns the IDs of the channels mentioned in a text, e.g. <#C123|general> in Slack
+	ParseChannels(text string) []string
+	// GetUser looks up the user's profile
+	GetUser(userID string) (ChatUser, error)
+	// ChannelMembers returns the IDs of the users in the channel, bots included
+	ChannelMembers(channelID string) ([]string, error)
+	// ChannelCreator returns the ID of the user who created the channel
+	ChannelCreator(channelID string) (string, error)
+	// IsAway returns true if the user isn't connected or is marked as away
+	IsAway(userID string) (bool, error)
+}
+
+// ChatUser represents the profile of a chat user
+type ChatUser struct {
+	ID       string
+	Name     string
+	RealName string
+	TimeZone string
+	Avatar   string
+	IsBot    bool
+}
@@ -0,0 +1,107 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"log"
+	"strings"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/content"
+)
+
+// getDailyContent returns the joke, comic or quote to share at the end of the Daily Meeting of the channel, or
+// an empty string if there isn't any
+func getDailyContent(channelID string) string {
+	channelsDailyMap.Lock()
+	source := channelsDailyMap.d[channelID].Content
+	channelsDailyMap.Unlock()
+
+	if source == "" {
+		return ""
+	}
+
+	p, err := content.New(source)
+	if err != nil {
+		log.Printf("slackutils: invalid content source in channel %s: %v", channelID, err)
+		return ""
+	}
+	item, err := p.Item()
+	if err != nil {
+		log.Printf("slackutils: error getting content for channel %s: %v", channelID, err)
+		return ""
+	}
+	return item.String()
+}
+
+func manageContentDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager daily content https://xkcd.com/atom.xml`, "+
+			"the name of a quotes file or `off`"),
+	}
+
+	source := m.getContentArgs()
+	var sample string
+	if strings.EqualFold(source, "off") {
+		source = ""
+	} else {
+		p, err := content.New(source)
+		if err != nil {
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return
+		}
+
+		// The source is tried before saving it, so a wrong URL or file is known now and not after the daily
+		item, err := p.Item()
+		if err != nil {
+			log.Printf("slackutils: error getting content from %s: %v", source, err)
+			message.Text = tr(m.getChannelID(), ":scream: I can't get anything from `%s`, check it's an RSS or Atom "+
+				"feed or a quotes file with one quote per line", source)
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return
+		}
+		sample = item.String()
+	}
+
+	_, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.Content = source
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! The Daily Meetings will end without fun content :neutral_face:")
+	if source != "" {
+		message.Text = tr(m.getChannelID(), "Done! The Daily Meetings will end with something like this :smile:") +
+			"\n" + sample
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// getContentArgs returns the source typed after `daily content`, without the brackets Slack adds to the URLs
+func (m Message) getContentArgs() string {
+	i := strings.Index(m.Text, "daily content")
+	if i < 0 {
+		return ""
+	}
+	source := strings.Trim(strings.TrimSpace(m.Text[i+len("daily content"):]), "`<>")
+	if j := strings.Index(source, "|"); j >= 0 {
+		source = source[:j]
+	}
+	return source
+}
@@ -0,0 +1,155 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+// startDailyDigest creates the digest of today's Daily Meeting. If the meeting is repeated, the answers already
+// given are kept
+func startDailyDigest(channelID string, started time.Time, threadTS string, memberIDs []string) {
+	digest := api.DailyDigest{
+		ChannelID: channelID,
+		Date:      started,
+		ThreadTS:  threadTS,
+		Members:   memberIDs,
+	}
+
+	previous, err := getDailyDigest(channelID, started)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve daily digest of channel %s: %v", channelID, err)
+	}
+	if previous != nil {
+		digest.Answers = previous.Answers
+		for _, id := range previous.Members {
+			if !digest.HasMember(id) {
+				digest.Members = append(digest.Members, id)
+			}
+		}
+	}
+
+	if err := storeDailyDigest(digest); err != nil {
+		log.Printf("slackutils: error invoking API Server to store daily digest of channel %s: %v", channelID, err)
+	}
+}
+
+// listOwedDigests returns the digests of today's Daily Meetings where the member still owes answers
+func listOwedDigests(memberID string) ([]api.DailyDigest, error) {
+	y, mo, d := time.Now().Date()
+	today := time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+
+	channelsDailyMap.Lock()
+	var channelIDs []string
+	for id, daily := range channelsDailyMap.d {
+		if !daily.LastDaily.Before(today) {
+			channelIDs = append(channelIDs, id)
+		}
+	}
+	channelsDailyMap.Unlock()
+
+	var digests []api.DailyDigest
+	for _, id := range channelIDs {
+		digest, err := getDailyDigest(id, today)
+		if err != nil {
+			return nil, err
+		}
+		if digest != nil && digest.HasMember(memberID) &&
+			digest.Answered(memberID) < len(api.DefaultQuestions[api.MeetingDaily]) {
+			digests = append(digests, *digest)
+		}
+	}
+	return digests, nil
+}
+
+// resumeDailyReport asks the member, in the channel given, the questions of the Daily Meeting they didn't answer
+// and adds the answers to the digest as a late report
+func resumeDailyReport(chat ChatAdapter, digest api.DailyDigest, askChannelID, memberID string) {
+	pendingResumes.finish(digest.ChannelID, memberID)
+
+	answers, err := runDailyByMember(chat, digest.ChannelID, askChannelID, memberID, "", digest.Answered(memberID), nil)
+	if len(answers) > 0 {
+		for i := range answers {
+			answers[i].Late = true
+		}
+		if err := addDigestAnswers(digest.ChannelID, digest.Date, answers); err != nil {
+			log.Printf("slackutils: error adding late answers of %s to the digest of channel %s: %v", memberID,
+				digest.ChannelID, err)
+		}
+
+		// The report given in private, or out of the thread of the meeting, is shared where the meeting was
+		if askChannelID != digest.ChannelID || digest.ThreadTS != "" {
+			if err := sendLateReportMsj(chat, digest, memberID, answers); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", digest.ChannelID, err)
+			}
+		}
+	}
+
+	if err == nil {
+		return
+	}
+	member, err := getTeamMember(digest.ChannelID, memberID)
+	if err != nil {
+		member = &api.Member{ID: memberID, ChannelID: digest.ChannelID}
+	}
+	markPendingResume(chat, digest.ChannelID, *member)
+}
+
+func sendLateReportMsj(chat ChatAdapter, digest api.DailyDigest, memberID string, answers []api.DailyAnswer) error {
+	questions := api.DefaultQuestions[api.MeetingDaily]
+
+	var b bytes.Buffer
+	b.WriteString(render(digest.ChannelID, "daily-late-report", map[string]interface{}{"Member": chat.Mention(memberID)}))
+	for _, a := range answers {
+		if a.Question < len(questions) {
+			b.WriteString("\n*" + capitalize(tr(digest.ChannelID, questions[a.Question])) + "*")
+		}
+		b.WriteString("\n" + a.Text)
+	}
+
+	message := &Message{
+		ID:       0,
+		Type:     "message",
+		Channel:  digest.ChannelID,
+		Text:     b.String(),
+		ThreadTS: digest.ThreadTS,
+	}
+	return message.send(chat)
+}
+
+func managePendingDaily(chat ChatAdapter, m *Message) {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+	}
+
+	digest, err := getDailyDigest(m.getChannelID(), time.Now())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve daily digest: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	if digest == nil {
+		message.Text = tr(m.getChannelID(),
+			"There hasn't been any Daily Meeting today, type `@leanmanager daily start` to start it")
+	} else if pending := digest.Pending(len(api.DefaultQuestions[api.MeetingDaily])); len(pending) == 0 {
+		message.Text = tr(m.getChannelID(), "Everyone has given their Daily report today :tada:")
+	} else {
+		mentions := make([]string, len(pending))
+		for i, p := range pending {
+			mentions[i] = chat.Mention(p)
+		}
+		message.Text = tr(m.getChannelID(), "Still waiting for the Daily report of %s :hourglass:",
+			strings.Join(mentions, ", "))
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
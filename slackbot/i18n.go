@@ -0,0 +1,109 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/i18n"
+)
+
+// getLanguage returns the language chosen for the channel, the default one if it hasn't chosen any. It doesn't lock
+// the dailies, so messages can be translated while they are locked
+func getLanguage(channelID string) string {
+	if lang := channelsPrefs.get(channelID).language; lang != "" {
+		return lang
+	}
+	return i18n.DefaultLanguage
+}
+
+// tr translates the text to the language of the channel, formatting it with the args if there are any
+func tr(channelID, text string, args ...interface{}) string {
+	return i18n.T(getLanguage(channelID), text, args...)
+}
+
+// formatDay writes the day in the language of the channel, like Monday, 19 October
+func formatDay(channelID string, t time.Time) string {
+	return tr(channelID, "%s, %02d %s", tr(channelID, t.Weekday().String()), t.Day(), tr(channelID, t.Month().String()))
+}
+
+// formatDayTime writes the day and the time in the language of the channel, like Monday, 19 October at 09:30
+func formatDayTime(channelID string, t time.Time) string {
+	return tr(channelID, "%s at %s", formatDay(channelID, t), t.Format("15:04"))
+}
+
+// capitalize upper-cases the first letter of the text, after the opening marks like the Spanish ¿
+func capitalize(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		if unicode.IsLetter(r) {
+			runes[i] = unicode.ToUpper(r)
+			break
+		}
+	}
+	return string(runes)
+}
+
+func manageLanguageDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	var langs []string
+	for _, l := range i18n.Languages() {
+		langs = append(langs, "`"+l+"` ("+i18n.Name(l)+")")
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager daily language es`, I speak %s",
+			strings.Join(langs, ", ")),
+	}
+
+	lang := strings.ToLower(strings.Trim(m.getLanguageArgs(), "`"))
+	if !i18n.IsSupported(lang) {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	_, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.Language = lang
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! From now on I'll speak %s in this channel :speech_balloon:",
+		i18n.Name(lang))
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// getLanguageArgs returns the text typed after `daily language`
+func (m Message) getLanguageArgs() string {
+	i := strings.Index(m.Text, "daily language")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(m.Text[i+len("daily language"):])
+}
+
+func (m Message) isLanguageDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily language") ||
+		strings.HasPrefix(m.Text, "leanmanager daily language")) {
+		return true
+	}
+
+	return false
+}
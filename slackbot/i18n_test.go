@@ -0,0 +1,149 @@
+package slackbot
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/i18n"
+)
+
+// verbPattern finds the verbs of a format, %% isn't one
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.\[\]]*[a-zA-Z%]`)
+
+// stringLiteral returns the value of a string literal, or of literals joined with +
+func stringLiteral(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := stringLiteral(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := stringLiteral(e.Y)
+		return x + y, ok
+	case *ast.ParenExpr:
+		return stringLiteral(e.X)
+	}
+	return "", false
+}
+
+// trLiterals returns the texts translated with tr in the sources of the package, by position
+func trLiterals(t *testing.T) map[string]string {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("error listing the sources: %v", err)
+	}
+
+	texts := map[string]string{}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", name, err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+			if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "tr" {
+				return true
+			}
+			if text, ok := stringLiteral(call.Args[1]); ok {
+				texts[fset.Position(call.Pos()).String()] = text
+			}
+			return true
+		})
+	}
+	return texts
+}
+
+// translatedKeys returns the texts translated out of a literal: help, templates, questions, names and days
+func translatedKeys() []string {
+	keys := append([]string{}, helpLines...)
+	for _, text := range api.DefaultTemplates {
+		// Templates like `{{.Member}}, {{.Question}}` have nothing to translate
+		if regexp.MustCompile(`[a-zA-Z]`).MatchString(regexp.MustCompile(`{{[^}]*}}`).ReplaceAllString(text, "")) {
+			keys = append(keys, text)
+		}
+	}
+	for _, questions := range api.DefaultQuestions {
+		keys = append(keys, questions...)
+	}
+	for _, name := range api.MeetingNames {
+		keys = append(keys, name)
+	}
+	for _, name := range api.RetroCategoryNames {
+		keys = append(keys, name)
+	}
+	keys = append(keys, api.RoleAdmin, api.RoleMember, api.RoleObserver,
+		api.OrderRandom, api.OrderRotation, api.OrderAlphabetical, api.OrderManual)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		keys = append(keys, d.String())
+	}
+	for m := time.January; m <= time.December; m++ {
+		keys = append(keys, m.String())
+	}
+	return keys
+}
+
+func TestLocalesTranslateEveryMessage(t *testing.T) {
+	texts := trLiterals(t)
+	if len(texts) == 0 {
+		t.Fatal("no tr calls found")
+	}
+	for _, key := range translatedKeys() {
+		texts[key] = key
+	}
+
+	// The messages are written in the default language, its catalog doesn't need them
+	for _, lang := range i18n.Languages() {
+		if lang == i18n.DefaultLanguage {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join("..", "i18n", "locales", lang+".json"))
+		if err != nil {
+			t.Fatalf("error reading locale %s: %v", lang, err)
+		}
+		var l struct {
+			Messages map[string]string `json:"messages"`
+		}
+		if err := json.Unmarshal(data, &l); err != nil {
+			t.Fatalf("error parsing locale %s: %v", lang, err)
+		}
+
+		for where, text := range texts {
+			translated, ok := l.Messages[text]
+			if !ok || translated == "" {
+				t.Errorf("%s: %s hasn't %q", where, lang, text)
+				continue
+			}
+			if got, want := len(verbPattern.FindAllString(translated, -1)),
+				len(verbPattern.FindAllString(text, -1)); got != want {
+				t.Errorf("%s: %s translates %q with %d verbs, want %d", where, lang, text, got, want)
+			}
+		}
+	}
+}
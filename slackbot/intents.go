@@ -0,0 +1,126 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/intent"
+)
+
+// confirmIntentTimeout is how long the bot waits for the member to confirm a destructive command typed in free text
+const confirmIntentTimeout = 2 * time.Minute
+
+// manageIntent runs the command typed in free text, like `add @bob to the daily`, as if it was typed as the bot
+// expects it. The commands deleting or interrupting something run only if the member confirms them. It returns
+// false if the text isn't understood
+func manageIntent(chat ChatAdapter, m Message, botID string) bool {
+	text, ok := m.getCommandText(chat.Mention(botID))
+	if !ok {
+		return false
+	}
+
+	var mentions []string
+	for _, u := range chat.ParseMentions(text) {
+		if u != botID {
+			mentions = append(mentions, chat.Mention(u))
+		}
+	}
+
+	i, ok := intent.Parse(text, mentions)
+	if !ok {
+		return false
+	}
+
+	// The command is already written as expected, it would be read again in a loop
+	command := "leanmanager " + i.String()
+	if strings.TrimSpace(m.Text) == command {
+		return false
+	}
+
+	log.Printf("slackbot: %q understood as %q in channel %s", m.Text, command, m.getChannelID())
+	if i.IsDestructive() && !confirmIntent(chat, m, i) {
+		return true
+	}
+	m.Text = command
+	manageMessage(m, botID, chat)
+	return true
+}
+
+// confirmIntent asks the member if the command understood is the one wanted, it returns true if they answer yes.
+// If the member is already answering something else, the command is only suggested
+func confirmIntent(chat ChatAdapter, m Message, i intent.Intent) bool {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), "Do you want me to run `@leanmanager %s`? Type `yes` or `no` :thinking_face:",
+			i.String()),
+	}
+
+	channelsMap.Lock()
+	if channelsMap.p[m.getChannelID()] == nil {
+		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+	}
+	if channelsMap.p[m.getChannelID()][m.User] != nil {
+		channelsMap.Unlock()
+		message.Text = tr(m.getChannelID(), ":interrobang: Did you mean %s?", "`@leanmanager "+i.String()+"`")
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return false
+	}
+	answers := make(chan Message)
+	channelsMap.p[m.getChannelID()][m.User] = answers
+	channelsMap.Unlock()
+	defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		return false
+	}
+
+	expired := time.After(confirmIntentTimeout)
+	for {
+		select {
+		case answer := <-answers:
+			if answer.isYes() {
+				return true
+			}
+			message.Text = ":ok_hand:"
+			if !answer.isNo() && !answer.isCancel() {
+				message.Text = tr(m.getChannelID(), ":scream: Type something like `yes`, `no` or `cancel`.")
+				if err := message.send(chat); err != nil {
+					log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+				}
+				continue
+			}
+		case <-expired:
+			message.Text = tr(m.getChannelID(), "No answer, I won't run `@leanmanager %s` :ok_hand:", i.String())
+		}
+
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return false
+	}
+}
+
+// getCommandText returns the text typed after the mention of the bot, ok is false if the message doesn't start
+// with the mention
+func (m Message) getCommandText(botMention string) (text string, ok bool) {
+	if m.Type != "message" {
+		return "", false
+	}
+
+	for _, prefix := range []string{botMention, "leanmanager"} {
+		if !strings.HasPrefix(m.Text, prefix) || len(m.Text) == len(prefix) {
+			continue
+		}
+		if rest := m.Text[len(prefix):]; strings.ContainsAny(rest[:1], " ,:") {
+			return strings.TrimSpace(strings.TrimLeft(rest, " ,:")), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,466 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+type mattermostEvent struct {
+	Event     string                 `json:"event"`
+	Data      map[string]interface{} `json:"data"`
+	Broadcast mattermostBroadcast    `json:"broadcast"`
+	Seq       int64                  `json:"seq"`
+}
+
+type mattermostBroadcast struct {
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+	TeamID    string `json:"team_id"`
+}
+
+type mattermostPost struct {
+	ID        string                 `json:"id,omitempty"`
+	ChannelID string                 `json:"channel_id"`
+	UserID    string                 `json:"user_id,omitempty"`
+	RootID    string                 `json:"root_id,omitempty"`
+	Message   string                 `json:"message"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
+type mattermostReaction struct {
+	UserID    string `json:"user_id"`
+	PostID    string `json:"post_id"`
+	EmojiName string `json:"emoji_name"`
+}
+
+type mattermostUser struct {
+	ID        string            `json:"id"`
+	Username  string            `json:"username"`
+	FirstName string            `json:"first_name"`
+	LastName  string            `json:"last_name"`
+	Timezone  map[string]string `json:"timezone"`
+	IsBot     bool              `json:"is_bot"`
+}
+
+type mattermostTeam struct {
+	ID string `json:"id"`
+}
+
+type mattermostChannel struct {
+	ID        string `json:"id"`
+	CreatorID string `json:"creator_id"`
+}
+
+type mattermostChannelMember struct {
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+}
+
+// MattermostChat connects the bot to a Mattermost server using its websocket and REST APIs
+type MattermostChat struct {
+	url   string
+	token string
+	botID string
+
+	users struct {
+		sync.Mutex
+		u       map[string]mattermostUser
+		byName  map[string]string
+		unknown map[string]time.Time
+	}
+
+	sync.RWMutex
+	ws *websocket.Conn
+}
+
+// NewMattermostChat returns a Mattermost adapter for the server URL (e.g. https://chat.example.com),
+// authenticated with the bot's access token
+func NewMattermostChat(serverURL, token string) *MattermostChat {
+	mm := &MattermostChat{
+		url:   strings.TrimSuffix(serverURL, "/"),
+		token: token,
+	}
+	mm.users.u = make(map[string]mattermostUser)
+	mm.users.byName = make(map[string]string)
+	mm.users.unknown = make(map[string]time.Time)
+	return mm
+}
+
+// Connect identifies the bot and opens the websocket to receive events
+func (mm *MattermostChat) Connect() (string, error) {
+	var me mattermostUser
+	if err := mm.doRequest("GET", "/users/me", nil, &me); err != nil {
+		return "", fmt.Errorf("mattermostchat: error identifying the bot: %s", err)
+	}
+	mm.cacheUser(me)
+	mm.botID = me.ID
+
+	wsURL := "ws" + strings.TrimPrefix(mm.url, "http") + "/api/v4/websocket"
+	config, err := websocket.NewConfig(wsURL, mm.url)
+	if err != nil {
+		return "", fmt.Errorf("mattermostchat: error configuring websocket: %s", err)
+	}
+	config.Header.Set("Authorization", "Bearer "+mm.token)
+
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("mattermostchat: error creating websocket: %s", err)
+	}
+
+	mm.Lock()
+	mm.ws = ws
+	mm.Unlock()
+	return me.ID, nil
+}
+
+// Receive translates the next relevant Mattermost event into a Message
+func (mm *MattermostChat) Receive() (Message, error) {
+	for {
+		var e mattermostEvent
+		if err := websocket.JSON.Receive(mm.conn(), &e); err != nil {
+			return Message{}, err
+		}
+
+		switch e.Event {
+		case "posted":
+			raw, ok := e.Data["post"].(string)
+			if !ok {
+				continue
+			}
+			var p mattermostPost
+			if err := json.Unmarshal([]byte(raw), &p); err != nil || p.UserID == mm.botID {
+				continue
+			}
+			channelType, _ := e.Data["channel_type"].(string)
+			return Message{
+				Type:     "message",
+				User:     p.UserID,
+				Channel:  p.ChannelID,
+				Text:     p.Message,
+				TS:       p.ID,
+				ThreadTS: p.RootID,
+				Direct:   channelType == "D",
+			}, nil
+		case "reaction_added", "reaction_removed":
+			raw, ok := e.Data["reaction"].(string)
+			if !ok {
+				continue
+			}
+			var r mattermostReaction
+			if err := json.Unmarshal([]byte(raw), &r); err != nil || r.UserID == mm.botID {
+				continue
+			}
+			return Message{
+				Type:     e.Event,
+				User:     r.UserID,
+				Reaction: r.EmojiName,
+				Item:     &MessageItem{Type: "message", Channel: e.Broadcast.ChannelID, TS: r.PostID},
+			}, nil
+		case "user_added":
+			if userID, _ := e.Data["user_id"].(string); userID != mm.botID {
+				continue
+			}
+			return Message{
+				Type:    "channel_joined",
+				Channel: map[string]interface{}{"id": e.Broadcast.ChannelID},
+			}, nil
+		case "status_change":
+			userID, _ := e.Data["user_id"].(string)
+			status, _ := e.Data["status"].(string)
+			if userID == "" || userID == mm.botID {
+				continue
+			}
+			presence := "away"
+			if status == "online" {
+				presence = "active"
+			}
+			return Message{
+				Type:     "presence_change",
+				User:     userID,
+				Presence: presence,
+			}, nil
+		case "user_removed":
+			userID, _ := e.Data["user_id"].(string)
+			if userID == "" || userID == mm.botID || e.Broadcast.ChannelID == "" {
+				continue
+			}
+			return Message{
+				Type:    "member_left_channel",
+				User:    userID,
+				Channel: e.Broadcast.ChannelID,
+			}, nil
+		}
+	}
+}
+
+// Send creates a post in the channel, or a reply if ThreadTS is set
+func (mm *MattermostChat) Send(m Message) error {
+	_, err := mm.SendWithAck(m)
+	return err
+}
+
+// SendWithAck creates the post and returns its ID, used as root of threads
+func (mm *MattermostChat) SendWithAck(m Message) (string, error) {
+	channelID, _ := m.Channel.(string)
+	p := mattermostPost{
+		ChannelID: channelID,
+		RootID:    m.ThreadTS,
+		Message:   m.Text,
+	}
+
+	// The persona is shown only if the server enables the integrations to override usernames and icons
+	if m.Username != "" || m.Icon != "" {
+		p.Props = map[string]interface{}{}
+		if m.Username != "" {
+			p.Props["override_username"] = m.Username
+		}
+		if strings.HasPrefix(m.Icon, ":") {
+			p.Props["override_icon_emoji"] = strings.Trim(m.Icon, ":")
+		} else if m.Icon != "" {
+			p.Props["override_icon_url"] = m.Icon
+		}
+	}
+
+	var created mattermostPost
+	if err := mm.doRequest("POST", "/posts", &p, &created); err != nil {
+		return "", fmt.Errorf("mattermostchat: error creating post in channel %s: %s", channelID, err)
+	}
+	return created.ID, nil
+}
+
+// SendDirect creates (or reuses) the direct channel between the bot and the user and posts the text there
+func (mm *MattermostChat) SendDirect(userID, text string) error {
+	var direct struct {
+		ID string `json:"id"`
+	}
+	if err := mm.doRequest("POST", "/channels/direct", []string{mm.botID, userID}, &direct); err != nil {
+		return fmt.Errorf("mattermostchat: error opening direct channel with %s: %s", userID, err)
+	}
+
+	_, err := mm.SendWithAck(Message{
+		Type:    "message",
+		Channel: direct.ID,
+		Text:    text,
+	})
+	return err
+}
+
+// Mention formats the user as @username
+func (mm *MattermostChat) Mention(userID string) string {
+	u, err := mm.getUser(userID)
+	if err != nil {
+		return "@" + userID
+	}
+	return "@" + u.Username
+}
+
+// MentionChannel notifies the members of the channel
+func (mm *MattermostChat) MentionChannel() string {
+	return "@channel"
+}
+
+// mattermostMentionPattern matches the @username mentions starting a word, so e-mail addresses aren't mentions
+var mattermostMentionPattern = regexp.MustCompile(`(?:^|[\s(\["'])@([A-Za-z0-9._-]*[A-Za-z0-9_-])`)
+
+// mattermostUnknownUserTTL is how long a username which isn't a user is remembered, so it isn't looked up again in
+// every message
+const mattermostUnknownUserTTL = 10 * time.Minute
+
+// ParseMentions returns the IDs of the @username mentions in the text, except @channel, @all and @here
+func (mm *MattermostChat) ParseMentions(text string) (userIDs []string) {
+	for _, match := range mattermostMentionPattern.FindAllStringSubmatch(text, -1) {
+		switch match[1] {
+		case "channel", "all", "here":
+			continue
+		}
+
+		if id, ok := mm.getUserIDByName(match[1]); ok {
+			userIDs = append(userIDs, id)
+		}
+	}
+	return userIDs
+}
+
+// getUserIDByName returns the ID of the user, looking it up in the server only if it isn't cached
+func (mm *MattermostChat) getUserIDByName(username string) (string, bool) {
+	mm.users.Lock()
+	id, ok := mm.users.byName[username]
+	missing, unknown := mm.users.unknown[username]
+	mm.users.Unlock()
+	if ok {
+		return id, true
+	}
+	if unknown && time.Since(missing) < mattermostUnknownUserTTL {
+		return "", false
+	}
+
+	var u mattermostUser
+	if err := mm.doRequest("GET", "/users/username/"+username, nil, &u); err != nil {
+		mm.users.Lock()
+		mm.users.unknown[username] = time.Now()
+		mm.users.Unlock()
+		return "", false
+	}
+	mm.cacheUser(u)
+	return u.ID, true
+}
+
+// ParseChannels returns the IDs of the ~channel-name mentions in the text, looking for them in the bot's teams
+func (mm *MattermostChat) ParseChannels(text string) (channelIDs []string) {
+	re := regexp.MustCompile("[~]([a-z0-9_-]+)")
+	matches := re.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var teams []mattermostTeam
+	if err := mm.doRequest("GET", "/users/me/teams", nil, &teams); err != nil {
+		return nil
+	}
+
+	for _, match := range matches {
+		for _, t := range teams {
+			var c mattermostChannel
+			if err := mm.doRequest("GET", "/teams/"+t.ID+"/channels/name/"+match[1], nil, &c); err == nil {
+				channelIDs = append(channelIDs, c.ID)
+				break
+			}
+		}
+	}
+	return channelIDs
+}
+
+// GetUser retrieves the user's profile
+func (mm *MattermostChat) GetUser(userID string) (ChatUser, error) {
+	u, err := mm.getUser(userID)
+	if err != nil {
+		return ChatUser{}, err
+	}
+	timeZone := u.Timezone["manualTimezone"]
+	if u.Timezone["useAutomaticTimezone"] == "true" {
+		timeZone = u.Timezone["automaticTimezone"]
+	}
+
+	return ChatUser{
+		ID:       u.ID,
+		Name:     u.Username,
+		RealName: strings.TrimSpace(u.FirstName + " " + u.LastName),
+		TimeZone: timeZone,
+		Avatar:   mm.url + "/api/v4/users/" + u.ID + "/image",
+		IsBot:    u.IsBot,
+	}, nil
+}
+
+// ChannelMembers retrieves all the pages of members of the channel
+func (mm *MattermostChat) ChannelMembers(channelID string) (members []string, err error) {
+	const perPage = 200
+	for page := 0; ; page++ {
+		var channelMembers []mattermostChannelMember
+		if err := mm.doRequest("GET", fmt.Sprintf("/channels/%s/members?page=%d&per_page=%d",
+			channelID, page, perPage), nil, &channelMembers); err != nil {
+			return nil, fmt.Errorf("mattermostchat: error retrieving members of channel %s: %s", channelID, err)
+		}
+
+		for _, cm := range channelMembers {
+			members = append(members, cm.UserID)
+		}
+		if len(channelMembers) < perPage {
+			return members, nil
+		}
+	}
+}
+
+// ChannelCreator retrieves the channel to read its creator
+func (mm *MattermostChat) ChannelCreator(channelID string) (string, error) {
+	var c mattermostChannel
+	if err := mm.doRequest("GET", "/channels/"+channelID, nil, &c); err != nil {
+		return "", fmt.Errorf("mattermostchat: error retrieving channel %s: %s", channelID, err)
+	}
+	return c.CreatorID, nil
+}
+
+// IsAway retrieves the user's status, online and dnd users are considered connected
+func (mm *MattermostChat) IsAway(userID string) (bool, error) {
+	var status struct {
+		Status string `json:"status"`
+	}
+	if err := mm.doRequest("GET", "/users/"+userID+"/status", nil, &status); err != nil {
+		return false, fmt.Errorf("mattermostchat: error retrieving status of %s: %s", userID, err)
+	}
+	return status.Status == "away" || status.Status == "offline", nil
+}
+
+func (mm *MattermostChat) getUser(userID string) (mattermostUser, error) {
+	mm.users.Lock()
+	u, ok := mm.users.u[userID]
+	mm.users.Unlock()
+	if ok {
+		return u, nil
+	}
+
+	if err := mm.doRequest("GET", "/users/"+userID, nil, &u); err != nil {
+		return mattermostUser{}, fmt.Errorf("mattermostchat: error retrieving user %s: %s", userID, err)
+	}
+	mm.cacheUser(u)
+	return u, nil
+}
+
+func (mm *MattermostChat) cacheUser(u mattermostUser) {
+	mm.users.Lock()
+	mm.users.u[u.ID] = u
+	mm.users.byName[u.Username] = u.ID
+	delete(mm.users.unknown, u.Username)
+	mm.users.Unlock()
+}
+
+func (mm *MattermostChat) conn() *websocket.Conn {
+	mm.RLock()
+	defer mm.RUnlock()
+	return mm.ws
+}
+
+func (mm *MattermostChat) doRequest(method, path string, in, out interface{}) error {
+	var buf bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&buf).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, mm.url+"/api/v4"+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+mm.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,404 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/scheduler"
+)
+
+// meetingTypeAliases are other ways to type the meeting types
+var meetingTypeAliases = map[string]string{
+	"retro":    api.MeetingRetrospective,
+	"check-in": api.MeetingCheckIn,
+}
+
+// launchScheduledMeetings starts the meetings with a pending occurrence, like launchScheduledTasks does with dailies
+func launchScheduledMeetings(chat ChatAdapter) {
+	meetings, err := listMeetings("")
+	if err != nil {
+		log.Printf("slackbot: error invoking API Server to retrieve meetings: %v", err)
+		return
+	}
+
+	t := time.Now()
+	for _, mt := range meetings {
+		s, err := scheduler.Parse(mt.Schedule)
+		if err != nil {
+			log.Printf("slackbot: invalid schedule of meeting %s in channel %s: %v", mt.ID, mt.ChannelID, err)
+			continue
+		}
+
+		since := t.Add(-missedDailyGrace)
+		if mt.LastRun.After(since) {
+			since = mt.LastRun
+		}
+		if scheduler.Pending(s, since, t).IsZero() {
+			continue
+		}
+
+		// The occurrence is marked before starting, so the next tick doesn't launch it again
+		mt.LastRun = t
+		if err := addMeeting(&mt); err != nil {
+			log.Printf("slackbot: error invoking API Server to store meeting %s: %v", mt.ID, err)
+			continue
+		}
+		go runMeeting(chat, mt)
+	}
+}
+
+// meetingName returns the name of the meeting in the language of its channel
+func meetingName(mt api.Meeting) string {
+	return tr(mt.ChannelID, mt.GetName())
+}
+
+func manageAddMeeting(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager meeting add retrospective 0 15 * * 5` or "+
+			"`@leanmanager meeting add planning FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;BYHOUR=10 with @member`. "+
+			"Meetings can be daily, retrospective, planning or checkin, type `anonymous` after `retrospective` "+
+			"to hide who wrote each idea"),
+	}
+
+	// Participants are mentioned after `with`, the schedule can contain @ (e.g. @weekly)
+	args := m.getMeetingArgs("add")
+	var participants []string
+	if i := strings.Index(strings.ToLower(args), " with "); i >= 0 {
+		participants = chat.ParseMentions(args[i:])
+		args = args[:i]
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	meetingType := strings.ToLower(fields[0])
+	if alias, ok := meetingTypeAliases[meetingType]; ok {
+		meetingType = alias
+	}
+
+	anonymous := meetingType == api.MeetingRetrospective && strings.EqualFold(fields[1], "anonymous")
+	if anonymous {
+		fields = fields[1:]
+	}
+
+	expr := scheduler.WithStart(strings.Trim(strings.Join(fields[1:], " "), "`"), time.Now())
+	s, err := scheduler.Parse(expr)
+	if len(fields) < 2 {
+		err = scheduler.ErrNotScheduled
+	}
+	if !api.IsValidMeetingType(meetingType) || err != nil {
+		if err != nil {
+			message.Text = ":scream: " + strings.TrimPrefix(err.Error(), "scheduler: ") + ". " +
+				strings.TrimPrefix(message.Text, ":scream: ")
+		}
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	mt := api.Meeting{
+		ChannelID:    m.getChannelID(),
+		Type:         meetingType,
+		Name:         api.MeetingNames[meetingType],
+		Schedule:     expr,
+		Participants: participants,
+		Anonymous:    anonymous,
+	}
+
+	channelsMap.Lock()
+	if channelsMap.p[m.getChannelID()] == nil {
+		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+	}
+	if channelsMap.p[m.getChannelID()][m.User] == nil {
+		channelsMap.p[m.getChannelID()][m.User] = make(chan Message)
+		defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
+	}
+	channelsMap.Unlock()
+
+	var defaults []string
+	for _, q := range api.DefaultQuestions[meetingType] {
+		defaults = append(defaults, tr(m.getChannelID(), q))
+	}
+	message.Text = tr(m.getChannelID(), "Which questions do you want me to make? Type one per line, or `default` "+
+		"to use these:\n• %s", strings.Join(defaults, "\n• "))
+	if meetingType == api.MeetingRetrospective {
+		message.Text = tr(m.getChannelID(), "Which questions do you want me to make? Type three, one per line, for "+
+			"what went well, what to improve and the actions to take, or `default` to use these:\n• %s",
+			strings.Join(defaults, "\n• "))
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+
+	messageReceived := <-channelsMap.p[m.getChannelID()][m.User]
+	if messageReceived.isCancel() {
+		message.Text = ":ok_hand:"
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(messageReceived.Text), "default") {
+		for _, q := range strings.Split(messageReceived.Text, "\n") {
+			if q = strings.TrimSpace(strings.TrimLeft(q, "•-* ")); q != "" {
+				mt.Questions = append(mt.Questions, q)
+			}
+		}
+	}
+
+	if err := addMeeting(&mt); err != nil {
+		log.Printf("slackutils: API Server is failing adding meeting to channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! %s `%s` scheduled", meetingName(mt), mt.ID)
+	if next := s.Next(time.Now()); !next.IsZero() {
+		message.Text += tr(m.getChannelID(), ", next one on %s", formatDayTime(m.getChannelID(), next))
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageListMeetings(chat ChatAdapter, m *Message) {
+	meetings, err := listMeetings(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve meetings of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), "There are no meetings besides the Daily Meeting, type "+
+			"`@leanmanager meeting add retrospective 0 15 * * 5` to add one"),
+	}
+
+	if len(meetings) > 0 {
+		var b bytes.Buffer
+		b.WriteString(tr(m.getChannelID(), "Meetings of the channel:"))
+		for _, mt := range meetings {
+			b.WriteString("\n• `" + mt.ID + "` " + tr(m.getChannelID(), "%s scheduled with `%s`", meetingName(mt),
+				mt.Schedule))
+			if mt.Anonymous {
+				b.WriteString(tr(m.getChannelID(), ", anonymous"))
+			}
+			if s, err := scheduler.Parse(mt.Schedule); err == nil {
+				if next := s.Next(time.Now()); !next.IsZero() {
+					b.WriteString(tr(m.getChannelID(), ", next one on %s", formatDayTime(m.getChannelID(), next)))
+				}
+			}
+			if len(mt.Participants) > 0 {
+				mentions := make([]string, len(mt.Participants))
+				for i, p := range mt.Participants {
+					mentions[i] = chat.Mention(p)
+				}
+				b.WriteString(tr(m.getChannelID(), ", with %s", strings.Join(mentions, ", ")))
+			}
+		}
+		message.Text = b.String()
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageDeleteMeeting(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager meeting delete retrospective-1`, "+
+			"see `meeting list`"),
+	}
+
+	if fields := strings.Fields(m.getMeetingArgs("delete")); len(fields) > 0 {
+		if err := delMeeting(m.getChannelID(), strings.Trim(fields[0], "`")); err == nil {
+			message.Text = tr(m.getChannelID(), "Done! Meeting `%s` deleted", strings.Trim(fields[0], "`"))
+		} else {
+			log.Printf("slackutils: error deleting meeting in channel %s: %v", m.getChannelID(), err)
+		}
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageStartMeeting(chat ChatAdapter, m *Message) {
+	fields := strings.Fields(m.getMeetingArgs("start"))
+
+	meetings, err := listMeetings(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve meetings of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	for _, mt := range meetings {
+		if len(fields) > 0 && mt.ID == strings.Trim(fields[0], "`") {
+			runMeeting(chat, mt)
+			return
+		}
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager meeting start retrospective-1`, "+
+			"see `meeting list`"),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// runMeeting asks the questions of the meeting to each participant. It shares the turns of the Daily Meeting, so
+// `daily skip` and `daily end` work and both can't run at the same time in a channel. Retrospectives are asked in
+// private instead, see runRetrospective
+func runMeeting(chat ChatAdapter, mt api.Meeting) {
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: mt.ChannelID,
+		Text: tr(mt.ChannelID, ":warning: There is another meeting running, the %s will wait for the next time",
+			meetingName(mt)),
+	}
+
+	if !runningDailies.start(mt.ChannelID) {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+		}
+		return
+	}
+	defer runningDailies.finish(mt.ChannelID)
+
+	participants := mt.Participants
+	if len(participants) == 0 {
+		teamMembers, err := listTeamMembers(mt.ChannelID)
+		if err != nil {
+			log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
+			_ = sendUnexpectedProblemMsj(chat, mt.ChannelID)
+			return
+		}
+		for _, tm := range teamMembers {
+			if !tm.IsObserver() {
+				participants = append(participants, tm.ID)
+			}
+		}
+	}
+
+	if len(participants) == 0 {
+		if err := sendNotMembersRegisteredMsj(chat, mt.ChannelID); err != nil {
+			log.Printf("slackutils: error listing member in channel %s: %s\n", mt.ChannelID, err)
+		}
+		return
+	}
+
+	mt.LastRun = time.Now()
+	if err := addMeeting(&mt); err != nil {
+		log.Printf("slackutils: error invoking API Server to store meeting %s: %v", mt.ID, err)
+	}
+
+	if mt.Type == api.MeetingRetrospective {
+		runRetrospective(chat, mt, participants)
+		return
+	}
+
+	message.Text = tr(mt.ChannelID, "Hi %s! Let's start the %s :mega:", chat.MentionChannel(), meetingName(mt))
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+	}
+
+	for _, p := range participants {
+		interrupt, ok := runningDailies.turn(mt.ChannelID, p)
+		if !ok {
+			continue
+		}
+		runMeetingByMember(chat, mt, p, interrupt)
+	}
+
+	message.Text = tr(mt.ChannelID, "%s done :tada: Thanks everyone!", meetingName(mt))
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+	}
+}
+
+func runMeetingByMember(chat ChatAdapter, mt api.Meeting, memberID string, interrupt <-chan struct{}) {
+	channelsMap.Lock()
+	if channelsMap.p[mt.ChannelID] == nil {
+		channelsMap.p[mt.ChannelID] = map[string]chan Message{}
+	}
+	if channelsMap.p[mt.ChannelID][memberID] == nil {
+		channelsMap.p[mt.ChannelID][memberID] = make(chan Message)
+		defer channelsMap.finishWaitingMember(mt.ChannelID, memberID)
+	}
+	channelsMap.Unlock()
+
+	_, _ = askQuestions(chat, mt.ChannelID, mt.ChannelID, memberID, "", mt.GetQuestions(), 0, mt.Type == api.MeetingDaily,
+		interrupt)
+}
+
+// getMeetingArgs returns the text typed after `meeting <command>`
+func (m Message) getMeetingArgs(command string) string {
+	i := strings.Index(m.Text, "meeting "+command)
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(m.Text[i+len("meeting "+command):])
+}
+
+func (m Message) isAddMeetingMsj(botMention string) bool {
+	return m.isMeetingMsj(botMention, "add")
+}
+
+func (m Message) isListMeetingsMsj(botMention string) bool {
+	return m.isMeetingMsj(botMention, "list")
+}
+
+func (m Message) isDeleteMeetingMsj(botMention string) bool {
+	return m.isMeetingMsj(botMention, "delete")
+}
+
+func (m Message) isStartMeetingMsj(botMention string) bool {
+	return m.isMeetingMsj(botMention, "start")
+}
+
+func (m Message) isMeetingMsj(botMention, command string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" meeting "+command) ||
+		strings.HasPrefix(m.Text, "leanmanager meeting "+command)) {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,346 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+const (
+	// moodReportDays is how many days the weekly mood chart shows
+	moodReportDays = 7
+
+	// moodSparks draw the mood chart, from the worst to the best average
+	moodSparks = "▁▂▃▄▅▆▇█"
+)
+
+// moodEmojis are the reactions, or emojis in the answer, understood as a mood score
+var moodEmojis = map[string]int{
+	"one": 1, "rage": 1, "angry": 1, "sob": 1, "tired_face": 1,
+	"two": 2, "disappointed": 2, "slightly_frowning_face": 2, "worried": 2, "confused": 2,
+	"three": 3, "neutral_face": 3, "expressionless": 3, "face_with_rolling_eyes": 3,
+	"four": 4, "slightly_smiling_face": 4, "smiley": 4, "relaxed": 4, "+1": 4, "thumbsup": 4,
+	"five": 5, "smile": 5, "grin": 5, "star-struck": 5, "heart_eyes": 5, "tada": 5,
+}
+
+// moodQuestionsController sends the reactions to the mood questions to the member who was asked
+type moodQuestionsController struct {
+	sync.Mutex
+	q map[string]moodQuestion
+}
+
+type moodQuestion struct {
+	memberID string
+	scores   chan int
+}
+
+var moodQuestions = moodQuestionsController{q: make(map[string]moodQuestion)}
+
+func (mq *moodQuestionsController) open(ts, memberID string) <-chan int {
+	mq.Lock()
+	defer mq.Unlock()
+	scores := make(chan int, 1)
+	mq.q[ts] = moodQuestion{memberID: memberID, scores: scores}
+	return scores
+}
+
+func (mq *moodQuestionsController) close(ts string) {
+	mq.Lock()
+	defer mq.Unlock()
+	delete(mq.q, ts)
+}
+
+// answer passes the reaction to the question if it was made by the member asked, returning false otherwise
+func (mq *moodQuestionsController) answer(ts, userID, reaction string) bool {
+	mq.Lock()
+	defer mq.Unlock()
+	q, ok := mq.q[ts]
+	if !ok || q.memberID != userID {
+		return false
+	}
+
+	// Skin tones come after the name, e.g. +1::skin-tone-2
+	score, ok := moodEmojis[strings.Split(reaction, "::")[0]]
+	if !ok {
+		return false
+	}
+	select {
+	case q.scores <- score:
+	default:
+	}
+	return true
+}
+
+// askMood asks the member in askChannelID to rate their mood, with a number or a reaction, if the Daily Meeting of
+// channelID asks it. Not answering only skips the question
+func askMood(chat ChatAdapter, channelID, askChannelID, memberID, threadTS string, interrupt <-chan struct{}) error {
+
+	channelsDailyMap.Lock()
+	enabled := channelsDailyMap.d[channelID].Mood
+	channelsDailyMap.Unlock()
+	if !enabled {
+		return nil
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: askChannelID,
+		Text: tr(channelID, "%s, how do you feel today? Type 1 :rage: to 5 :star-struck: or react to this message",
+			chat.Mention(memberID)),
+		ThreadTS: threadTS,
+	}
+	message.Username, message.Icon = getPersona(channelID)
+	ts, err := message.sendWithAck(chat)
+	if err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", askChannelID, err)
+		return nil
+	}
+
+	scores := moodQuestions.open(ts, memberID)
+	defer moodQuestions.close(ts)
+
+	_, limit := getDailyTimeouts(channelID)
+	expired := time.After(limit)
+
+	var score int
+	for score == 0 {
+		select {
+		case score = <-scores:
+		case m := <-channelsMap.p[askChannelID][memberID]:
+			if !m.isInThread(threadTS) {
+				continue
+			}
+			s, err := m.getValidMood()
+			if err != nil {
+				message.Text = tr(channelID, ":scream: Type a number from 1 to 5, or react to the question")
+				if err := message.send(chat); err != nil {
+					log.Printf("slackutils: error sending message to channel %s: %s\n", askChannelID, err)
+				}
+				continue
+			}
+			score = s
+		case <-expired:
+			return nil
+		case <-interrupt:
+			return errTurnInterrupted
+		}
+	}
+
+	if err := addMood(api.Mood{ChannelID: channelID, MemberID: memberID, Score: score}); err != nil {
+		log.Printf("slackutils: error invoking API Server to store mood of %s: %v", memberID, err)
+	}
+	return nil
+}
+
+// postWeeklyMoodReport posts the mood chart once a week, after the Daily Meeting
+func postWeeklyMoodReport(chat ChatAdapter, channelID string) {
+	channelsDailyMap.Lock()
+	d := channelsDailyMap.d[channelID]
+	due := d.Mood && time.Since(d.LastMoodReport) >= moodReportDays*24*time.Hour
+	if due {
+		d.LastMoodReport = time.Now()
+		channelsDailyMap.set(channelID, d)
+	}
+	channelsDailyMap.Unlock()
+
+	if !due {
+		return
+	}
+
+	if err := persistDaily(channelID); err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", channelID, err)
+	}
+
+	if err := sendMoodReportMsj(chat, channelID); err != nil {
+		log.Printf("slackutils: error sending mood report to channel %s: %s\n", channelID, err)
+	}
+}
+
+func sendMoodReportMsj(chat ChatAdapter, channelID string) error {
+	trend, err := getMoodTrend(channelID, moodReportDays)
+	if err != nil {
+		return err
+	}
+
+	m := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: channelID,
+		Text: tr(channelID, "Not enough members told me how they feel in the last week, I need %d answers to keep "+
+			"them anonymous :thinking_face:", api.MinMoodAnswers),
+	}
+
+	if len(trend) > 0 {
+		total, answers := 0.0, 0
+		for _, t := range trend {
+			total += t.Average * float64(t.Answers)
+			answers += t.Answers
+		}
+
+		y, mo, d := time.Now().AddDate(0, 0, 1-moodReportDays).Date()
+		from := time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+		chart, days := moodSparkline(trend, from, moodReportDays)
+		m.Text = tr(channelID, "Mood of the team in the last week :bar_chart:\n`%s`\n`%s`\n"+
+			"%.1f out of 5 in average, %d answers", chart, days, total/float64(answers), answers)
+	}
+
+	return m.send(chat)
+}
+
+// moodSparkline draws a bar for each day, a dot for the days without answers, and the initials of the days
+func moodSparkline(trend []api.MoodTrend, from time.Time, days int) (chart, initials string) {
+	averages := map[string]float64{}
+	for _, t := range trend {
+		averages[t.Date.Format("2006-01-02")] = t.Average
+	}
+
+	sparks := []rune(moodSparks)
+	var c, d strings.Builder
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i)
+		d.WriteString(day.Weekday().String()[:1])
+
+		average, ok := averages[day.Format("2006-01-02")]
+		if !ok {
+			c.WriteString("·")
+			continue
+		}
+		level := int(math.Round((average - api.MinMood) / (api.MaxMood - api.MinMood) * float64(len(sparks)-1)))
+		if level < 0 {
+			level = 0
+		} else if level >= len(sparks) {
+			level = len(sparks) - 1
+		}
+		c.WriteRune(sparks[level])
+	}
+
+	return c.String(), d.String()
+}
+
+func manageMoodDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	channelsMap.Lock()
+	if channelsMap.p[m.getChannelID()] == nil {
+		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+	}
+	if channelsMap.p[m.getChannelID()][m.User] == nil {
+		channelsMap.p[m.getChannelID()][m.User] = make(chan Message)
+		defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
+	}
+	channelsMap.Unlock()
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		User:    "",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), "Do you want me to ask how everyone feels in the Daily Meeting? :thermometer:"),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+
+	var messageReceived Message
+
+	for {
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
+		if messageReceived.isCancel() {
+			message.Text = ":ok_hand:"
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return
+		}
+
+		if messageReceived.isYes() || messageReceived.isNo() {
+			break
+		}
+
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `yes`, `no` or `cancel`.")
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+	}
+
+	d, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.Mood = messageReceived.isYes()
+		if d.Mood && d.LastMoodReport.IsZero() {
+			// The first chart is posted after a whole week
+			d.LastMoodReport = time.Now()
+		}
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	if d.Mood {
+		message.Text = tr(m.getChannelID(), "Done! I'll ask how everyone feels and post the mood of the team every "+
+			"week :bar_chart:")
+	} else {
+		message.Text = tr(m.getChannelID(), "Done! No more questions about the mood :+1:")
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageMoodReportDaily(chat ChatAdapter, m *Message) {
+	if err := sendMoodReportMsj(chat, m.getChannelID()); err != nil {
+		log.Printf("slackutils: error sending mood report to channel %s: %s\n", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+	}
+}
+
+func (m Message) getValidMood() (int, error) {
+	if m.Type != "message" {
+		return -1, fmt.Errorf("no type message")
+	}
+
+	text := strings.TrimSpace(m.Text)
+	if score, err := strconv.Atoi(text); err == nil {
+		if score < api.MinMood || score > api.MaxMood {
+			return -1, fmt.Errorf("mood out of range")
+		}
+		return score, nil
+	}
+
+	re := regexp.MustCompile("^:([a-z0-9_+-]+)(::[a-z0-9-]+)?:$")
+	if match := re.FindStringSubmatch(text); match != nil {
+		if score, ok := moodEmojis[match[1]]; ok {
+			return score, nil
+		}
+	}
+
+	return -1, fmt.Errorf("mood not found")
+}
+
+func (m Message) isMoodDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily mood") ||
+		strings.HasPrefix(m.Text, "leanmanager daily mood")) {
+		return true
+	}
+	return false
+}
+
+func (m Message) isMoodReportDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily mood report") ||
+		strings.HasPrefix(m.Text, "leanmanager daily mood report")) {
+		return true
+	}
+	return false
+}
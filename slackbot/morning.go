@@ -0,0 +1,277 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/scheduler"
+)
+
+// noImpedimentPattern reads the answers to the impediments question that mean there aren't any
+var noImpedimentPattern = regexp.MustCompile(`^(no|nop|nope|none|nothing|n/a)( impediments?| blockers?)?$`)
+
+// launchScheduledMornings greets the channels with a pending occurrence of their Good morning schedule
+func launchScheduledMornings(chat ChatAdapter) {
+	t := time.Now()
+
+	var due []api.DailyMeeting
+	channelsDailyMap.Lock()
+	for id, d := range channelsDailyMap.d {
+		if d.MorningSchedule == "" {
+			continue
+		}
+		s, err := scheduler.Parse(d.MorningSchedule)
+		if err != nil {
+			log.Printf("slackbot: invalid good morning schedule in channel %s: %v", id, err)
+			continue
+		}
+
+		since := t.Add(-missedDailyGrace)
+		if d.LastMorning.After(since) {
+			since = d.LastMorning
+		}
+		if scheduler.Pending(s, since, t).IsZero() {
+			continue
+		}
+
+		// The occurrence is marked before greeting, so the next tick doesn't greet again
+		d.LastMorning = t
+		channelsDailyMap.set(id, d)
+		due = append(due, d)
+	}
+	channelsDailyMap.Unlock()
+
+	for _, d := range due {
+		if err := persistDaily(d.ChannelID); err != nil {
+			log.Printf("slackbot: error invoking API Server to store the daily of channel %s: %v", d.ChannelID, err)
+		}
+		go func(channelID string) {
+			if err := sendGoodMorningMsj(chat, channelID); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+			}
+		}(d.ChannelID)
+	}
+}
+
+// sendGoodMorningMsj greets the team with today's agenda: the Daily Meeting, who is absent, the open impediments
+// and the reminders still to come
+func sendGoodMorningMsj(chat ChatAdapter, channelID string) error {
+	now := time.Now()
+	y, mo, day := now.Date()
+	today := time.Date(y, mo, day, 0, 0, 0, 0, time.Local)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	channelsDailyMap.Lock()
+	d := channelsDailyMap.d[channelID]
+	channelsDailyMap.Unlock()
+
+	var b bytes.Buffer
+	b.WriteString(render(channelID, "good-morning", map[string]interface{}{"Date": formatDay(channelID, now)}))
+
+	b.WriteString("\n• " + formatTodayDaily(d, today, tomorrow))
+
+	absences, err := listAbsences(channelID)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve absences of channel %s: %v", channelID, err)
+	}
+	var absent []string
+	for _, a := range absences {
+		if !a.Covers(now) {
+			continue
+		}
+		text := chat.Mention(a.MemberID)
+		if !a.To.Before(tomorrow) {
+			text += tr(channelID, " (until %s)", formatDay(channelID, a.To))
+		}
+		absent = append(absent, text)
+	}
+	if len(absent) == 0 {
+		b.WriteString("\n• " + tr(channelID, "Everyone is here today :muscle:"))
+	} else {
+		b.WriteString("\n• " + tr(channelID, "Absent: %s", strings.Join(absent, ", ")))
+	}
+
+	impediments := listOpenImpediments(channelID, d.LastDaily)
+	if len(impediments) > 0 {
+		b.WriteString("\n• " + tr(channelID, "Open impediments :construction:"))
+		for _, i := range impediments {
+			b.WriteString("\n    " + chat.Mention(i.MemberID) + ": " + i.Text)
+		}
+	} else {
+		b.WriteString("\n• " + tr(channelID, "No open impediments :tada:"))
+	}
+
+	reminders, err := listReminders(channelID)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve reminders of channel %s: %v", channelID, err)
+	}
+	for _, r := range reminders {
+		at := r.At
+		if r.Schedule != "" {
+			s, err := scheduler.Parse(r.Schedule)
+			if err != nil {
+				continue
+			}
+			at = s.Next(now)
+		}
+		if at.After(now) && at.Before(tomorrow) {
+			b.WriteString("\n• " + tr(channelID, "Reminder at %s to %s: \"%s\"", at.Format("15:04"), r.Recipient, r.Text))
+		}
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: channelID,
+		Text:    b.String(),
+	}
+	return message.send(chat)
+}
+
+// formatTodayDaily tells if there is a Daily Meeting today and when
+func formatTodayDaily(d api.DailyMeeting, today, tomorrow time.Time) string {
+	s, err := scheduler.ForDaily(d)
+	if err != nil {
+		return tr(d.ChannelID, "There is no Daily Meeting scheduled, type `@leanmanager daily schedule` to schedule one")
+	}
+
+	next := s.Next(today.Add(-time.Second))
+	if next.IsZero() || !next.Before(tomorrow) {
+		return tr(d.ChannelID, "There is no Daily Meeting today")
+	}
+	if !d.LastDaily.Before(today) {
+		return tr(d.ChannelID, "The Daily Meeting was already done today :white_check_mark:")
+	}
+	return tr(d.ChannelID, "The Daily Meeting is at %s :calendar:", next.Format("15:04"))
+}
+
+// listOpenImpediments returns the impediments reported in the last Daily Meeting, the last answer of each member
+func listOpenImpediments(channelID string, lastDaily time.Time) []api.DailyAnswer {
+	if lastDaily.IsZero() {
+		return nil
+	}
+
+	digest, err := getDailyDigest(channelID, lastDaily)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve daily digest of channel %s: %v", channelID, err)
+	}
+	if digest == nil {
+		return nil
+	}
+
+	var impediments []api.DailyAnswer
+	index := map[string]int{}
+	for _, a := range digest.Answers {
+		if a.Question != api.ImpedimentsQuestion {
+			continue
+		}
+		i, ok := index[a.MemberID]
+		if !ok {
+			i = len(impediments)
+			index[a.MemberID] = i
+			impediments = append(impediments, a)
+		}
+		impediments[i] = a
+	}
+
+	var open []api.DailyAnswer
+	for _, i := range impediments {
+		if !isNoImpediment(i.Text) {
+			open = append(open, i)
+		}
+	}
+	return open
+}
+
+func isNoImpediment(text string) bool {
+	t := strings.Trim(strings.ToLower(strings.TrimSpace(text)), ".!-:")
+	return t == "" || noImpedimentPattern.MatchString(t)
+}
+
+func manageMorning(chat ChatAdapter, m *Message) {
+	if err := sendGoodMorningMsj(chat, m.getChannelID()); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageScheduleMorning(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager morning schedule weekdays 8:30`, "+
+			"a cron expression or `off` to stop the Good morning"),
+	}
+
+	args := m.getMorningArgs("schedule")
+	expr := ""
+	if !strings.EqualFold(args, "off") {
+		var err error
+		if expr, err = scheduler.Every(args, time.Now()); err != nil || args == "" {
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return
+		}
+	}
+
+	_, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.MorningSchedule = expr
+		d.LastMorning = time.Now()
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! No more Good morning :zipper_mouth_face:")
+	if expr != "" {
+		message.Text = tr(m.getChannelID(), "Done! I'll say Good morning with `%s`", expr)
+		if s, err := scheduler.Parse(expr); err == nil {
+			if next := s.Next(time.Now()); !next.IsZero() {
+				message.Text += tr(m.getChannelID(), ", next time on %s", formatDayTime(m.getChannelID(), next))
+			}
+		}
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// getMorningArgs returns the text typed after `morning <command>`
+func (m Message) getMorningArgs(command string) string {
+	i := strings.Index(m.Text, "morning "+command)
+	if i < 0 {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(m.Text[i+len("morning "+command):]), "`")
+}
+
+func (m Message) isMorningMsj(botMention string) bool {
+	text := strings.TrimSpace(m.Text)
+	if m.Type == "message" && (text == botMention+" morning" || text == "leanmanager morning") {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isScheduleMorningMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" morning schedule") ||
+		strings.HasPrefix(m.Text, "leanmanager morning schedule")) {
+		return true
+	}
+
+	return false
+}
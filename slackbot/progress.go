@@ -0,0 +1,227 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+// dailyProgressController keeps the state of the Daily Meetings in progress, saving it in the API Server after
+// every step so they can be resumed if the bot restarts
+type dailyProgressController struct {
+	sync.Mutex
+	p map[string]*api.DailyProgress
+}
+
+var dailyProgresses = dailyProgressController{p: make(map[string]*api.DailyProgress)}
+
+// start begins to track the Daily Meeting, restored progresses keep the steps already done
+func (dp *dailyProgressController) start(progress api.DailyProgress) {
+	dp.Lock()
+	dp.p[progress.ChannelID] = &progress
+	dp.Unlock()
+	dp.save(progress.ChannelID)
+}
+
+// finish stops tracking the Daily Meeting, it won't be resumed anymore
+func (dp *dailyProgressController) finish(channelID string) {
+	dp.Lock()
+	delete(dp.p, channelID)
+	dp.Unlock()
+
+	if err := delDailyProgress(channelID); err != nil {
+		log.Printf("slackutils: error deleting daily progress of channel %s: %v", channelID, err)
+	}
+}
+
+// turn marks the member as the one speaking, keeping the answers if they were already speaking
+func (dp *dailyProgressController) turn(channelID, memberID string) {
+	dp.update(channelID, func(p *api.DailyProgress) bool {
+		if p.Current == memberID {
+			return false
+		}
+		p.Current = memberID
+		p.Question = 0
+		return true
+	})
+}
+
+// answer records the answer if the member is the one speaking, adding it to the digest of the day
+func (dp *dailyProgressController) answer(channelID, memberID string, question int, text string) {
+	answer := api.DailyAnswer{
+		MemberID: memberID,
+		Question: question,
+		Text:     text,
+		Date:     time.Now(),
+	}
+
+	var started time.Time
+	dp.update(channelID, func(p *api.DailyProgress) bool {
+		if p.Current != memberID {
+			return false
+		}
+		p.Answers = append(p.Answers, answer)
+		p.Question = question + 1
+		started = p.Started
+		return true
+	})
+	if started.IsZero() {
+		return
+	}
+
+	if err := addDigestAnswers(channelID, started, []api.DailyAnswer{answer}); err != nil {
+		log.Printf("slackutils: error adding answer of %s to the digest of channel %s: %v", memberID, channelID, err)
+	}
+}
+
+// done marks the turn of the member as finished
+func (dp *dailyProgressController) done(channelID, memberID string) {
+	dp.update(channelID, func(p *api.DailyProgress) bool {
+		p.Done = append(p.Done, memberID)
+		p.Current = ""
+		p.Question = 0
+		return true
+	})
+}
+
+func (dp *dailyProgressController) skip(channelID, memberID string) {
+	dp.update(channelID, func(p *api.DailyProgress) bool {
+		p.Skipped = append(p.Skipped, memberID)
+		return true
+	})
+}
+
+func (dp *dailyProgressController) snooze(channelID string, until time.Time) {
+	dp.update(channelID, func(p *api.DailyProgress) bool {
+		p.SnoozedUntil = until
+		return true
+	})
+}
+
+// answered returns how many questions the member has answered if they are the one speaking
+func (dp *dailyProgressController) answered(channelID, memberID string) int {
+	dp.Lock()
+	defer dp.Unlock()
+	if p := dp.p[channelID]; p != nil && p.Current == memberID {
+		return p.Question
+	}
+	return 0
+}
+
+func (dp *dailyProgressController) isDone(channelID, memberID string) bool {
+	dp.Lock()
+	defer dp.Unlock()
+	if p := dp.p[channelID]; p != nil {
+		for _, d := range p.Done {
+			if d == memberID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// update changes the progress of the channel with the function given, saving it if the function returns true
+func (dp *dailyProgressController) update(channelID string, f func(p *api.DailyProgress) bool) {
+	dp.Lock()
+	p := dp.p[channelID]
+	changed := p != nil && f(p)
+	dp.Unlock()
+
+	if changed {
+		dp.save(channelID)
+	}
+}
+
+func (dp *dailyProgressController) save(channelID string) {
+	dp.Lock()
+	p := dp.p[channelID]
+	if p == nil {
+		dp.Unlock()
+		return
+	}
+	p.Updated = time.Now()
+	progress := *p
+	dp.Unlock()
+
+	if err := storeDailyProgress(progress); err != nil {
+		log.Printf("slackutils: error saving daily progress of channel %s: %v", channelID, err)
+	}
+}
+
+// restoreDailies resumes the Daily Meetings that were in progress when the bot stopped
+func restoreDailies(chat ChatAdapter) {
+	progresses, err := listDailyProgresses()
+	if err != nil {
+		log.Printf("slackbot: error invoking API Server to retrieve dailies in progress: %v", err)
+		return
+	}
+
+	for _, p := range progresses {
+		// Nobody expects a Daily Meeting left hours ago to go on
+		if time.Since(p.Updated) > missedDailyGrace {
+			log.Printf("slackbot: daily of channel %s stopped at %s, too long ago to resume it", p.ChannelID, p.Updated)
+			if err := delDailyProgress(p.ChannelID); err != nil {
+				log.Printf("slackbot: error deleting daily progress of channel %s: %v", p.ChannelID, err)
+			}
+			continue
+		}
+
+		if !runningDailies.start(p.ChannelID) {
+			continue
+		}
+		go resumeDaily(chat, p)
+	}
+}
+
+// resumeDaily goes on with the Daily Meeting where it was left, runningDailies must be already started
+func resumeDaily(chat ChatAdapter, p api.DailyProgress) {
+	defer runningDailies.finish(p.ChannelID)
+
+	runningDailies.setFacilitator(p.ChannelID, p.Facilitator)
+	for _, s := range p.Skipped {
+		runningDailies.skip(p.ChannelID, s)
+	}
+
+	// Members removed since then are still asked, as they were in the meeting
+	teamMembers, err := listTeamMembers(p.ChannelID)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
+	}
+	known := map[string]api.Member{}
+	for _, tm := range teamMembers {
+		known[tm.ID] = tm
+	}
+	members := make([]api.Member, len(p.Members))
+	for i, id := range p.Members {
+		if tm, ok := known[id]; ok {
+			members[i] = tm
+		} else {
+			members[i] = api.Member{ID: id, ChannelID: p.ChannelID}
+		}
+	}
+
+	dailyProgresses.start(p)
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: p.ChannelID,
+		Text: tr(p.ChannelID, "Hi %s! I'm back :recycle: Resuming the Daily Meeting where we left off",
+			chat.MentionChannel()),
+		ThreadTS: p.ThreadTS,
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", p.ChannelID, err)
+	}
+
+	if time.Now().Before(p.SnoozedUntil) {
+		runningDailies.snooze(p.ChannelID, p.SnoozedUntil)
+		waitDailySnooze(chat, p.ChannelID, p.ThreadTS)
+	}
+
+	runDaily(chat, p.ChannelID, members, p.ThreadTS)
+}
@@ -0,0 +1,305 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/scheduler"
+)
+
+// reminderTimeLayout is how the day and hour of one-off reminders are typed
+const reminderTimeLayout = "2006-01-02 15:04"
+
+// launchScheduledReminders sends the reminders with a pending occurrence, like launchScheduledMeetings does with
+// meetings. One-off reminders are deleted once sent
+func launchScheduledReminders(chat ChatAdapter) {
+	reminders, err := listReminders("")
+	if err != nil {
+		log.Printf("slackbot: error invoking API Server to retrieve reminders: %v", err)
+		return
+	}
+
+	t := time.Now()
+	for _, r := range reminders {
+		if r.Schedule == "" {
+			if r.At.After(t) {
+				continue
+			}
+			if t.Sub(r.At) <= missedDailyGrace {
+				go sendReminder(chat, r)
+			} else {
+				log.Printf("slackbot: reminder %d of channel %s was due on %s, too long ago to send it", r.ID,
+					r.ChannelID, r.At)
+			}
+			if err := delReminder(r.ChannelID, r.ID); err != nil {
+				log.Printf("slackbot: error invoking API Server to delete reminder %d: %v", r.ID, err)
+			}
+			continue
+		}
+
+		s, err := scheduler.Parse(r.Schedule)
+		if err != nil {
+			log.Printf("slackbot: invalid schedule of reminder %d in channel %s: %v", r.ID, r.ChannelID, err)
+			continue
+		}
+
+		since := t.Add(-missedDailyGrace)
+		if r.LastRun.After(since) {
+			since = r.LastRun
+		}
+		if scheduler.Pending(s, since, t).IsZero() {
+			continue
+		}
+
+		// The occurrence is marked before sending, so the next tick doesn't send it again
+		r.LastRun = t
+		if err := addReminder(&r); err != nil {
+			log.Printf("slackbot: error invoking API Server to store reminder %d: %v", r.ID, err)
+			continue
+		}
+		go sendReminder(chat, r)
+	}
+}
+
+// sendReminder posts the reminder in its channel or sends it privately to its member
+func sendReminder(chat ChatAdapter, r api.Reminder) {
+	data := map[string]interface{}{"Author": "", "Text": r.Text}
+	if r.Author != "" {
+		data["Author"] = chat.Mention(r.Author)
+	}
+	text := render(r.ChannelID, "reminder", data)
+
+	if r.TargetMember != "" {
+		if err := chat.SendDirect(r.TargetMember, text); err != nil {
+			log.Printf("slackutils: error sending reminder %d to %s: %s\n", r.ID, r.TargetMember, err)
+		}
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: r.TargetChannel,
+		Text:    text,
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", r.TargetChannel, err)
+	}
+}
+
+func manageAddReminder(chat ChatAdapter, m *Message) {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager remind @member \"fill your hours\" "+
+			"every friday 16:00` or `@leanmanager remind #channel \"release day!\" on 2026-11-02 09:00`. Use `me` or "+
+			"`here` to remind yourself or this channel"),
+	}
+
+	r, err := m.getValidReminder(chat)
+	if err != nil {
+		if err != errInvalidReminder {
+			message.Text = ":scream: " + strings.TrimPrefix(err.Error(), "scheduler: ") + ". " +
+				strings.TrimPrefix(message.Text, ":scream: ")
+		}
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	if err := addReminder(r); err != nil {
+		log.Printf("slackutils: API Server is failing adding reminder to channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! Reminder `%d` %s", r.ID, formatReminderTime(*r))
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageListReminders(chat ChatAdapter, m *Message) {
+	reminders, err := listReminders(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve reminders of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), "There are no reminders, type `@leanmanager remind @member \"fill your hours\" "+
+			"every friday 16:00` to add one"),
+	}
+
+	if len(reminders) > 0 {
+		var b bytes.Buffer
+		b.WriteString(tr(m.getChannelID(), "Reminders of the channel:"))
+		for _, r := range reminders {
+			b.WriteString("\n• `" + strconv.Itoa(r.ID) + "` " + tr(m.getChannelID(), "to %s: \"%s\", %s", r.Recipient,
+				r.Text, formatReminderTime(r)))
+		}
+		message.Text = b.String()
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageDeleteReminder(chat ChatAdapter, m *Message) {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), ":scream: Type something like `@leanmanager remind delete 3`, see `remind list`"),
+	}
+
+	reminders, err := listReminders(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve reminders of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	fields := strings.Fields(m.getReminderArgs(" delete"))
+	if len(fields) > 0 {
+		if id, err := strconv.Atoi(strings.Trim(fields[0], "`#")); err == nil {
+			message.Text = tr(m.getChannelID(), ":thinking_face: There is no reminder `%d`, see `remind list`", id)
+			for _, r := range reminders {
+				if r.ID != id {
+					continue
+				}
+				if r.Author != m.User && !isAdmin(m.getChannelID(), m.User) {
+					message.Text = tr(m.getChannelID(), ":no_entry: Only who created the reminder or an admin can "+
+						"delete it")
+					break
+				}
+				if err := delReminder(m.getChannelID(), id); err != nil {
+					log.Printf("slackutils: error deleting reminder in channel %s: %v", m.getChannelID(), err)
+					_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+					return
+				}
+				message.Text = tr(m.getChannelID(), "Done! Reminder `%d` deleted", id)
+			}
+		}
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// formatReminderTime tells when the reminder will be sent, in the language of its channel
+func formatReminderTime(r api.Reminder) string {
+	if r.Schedule == "" {
+		return tr(r.ChannelID, "on %s", formatDayTime(r.ChannelID, r.At))
+	}
+
+	text := tr(r.ChannelID, "every `%s`", r.Schedule)
+	if s, err := scheduler.Parse(r.Schedule); err == nil {
+		if next := s.Next(time.Now()); !next.IsZero() {
+			text += tr(r.ChannelID, ", next one on %s", formatDayTime(r.ChannelID, next))
+		}
+	}
+	return text
+}
+
+// getValidReminder reads `remind <target> "text" every <schedule>|on <day hour>`, the target is a member, a
+// channel, `me` or `here`
+func (m Message) getValidReminder(chat ChatAdapter) (*api.Reminder, error) {
+	args := m.getReminderArgs("")
+	loc := regexp.MustCompile("[\"“”]([^\"“”]+)[\"“”]").FindStringSubmatchIndex(args)
+	if loc == nil {
+		return nil, errInvalidReminder
+	}
+
+	r := &api.Reminder{
+		ChannelID: m.getChannelID(),
+		Recipient: strings.TrimSpace(args[:loc[0]]),
+		Text:      strings.TrimSpace(args[loc[2]:loc[3]]),
+		Author:    m.User,
+	}
+
+	switch strings.ToLower(r.Recipient) {
+	case "me":
+		r.TargetMember = m.User
+		r.Recipient = chat.Mention(m.User)
+	case "here":
+		r.TargetChannel = m.getChannelID()
+	default:
+		if users := chat.ParseMentions(r.Recipient); len(users) > 0 {
+			r.TargetMember = users[0]
+		} else if channels := chat.ParseChannels(r.Recipient); len(channels) > 0 {
+			r.TargetChannel = channels[0]
+		} else {
+			return nil, errInvalidReminder
+		}
+	}
+
+	when := strings.TrimSpace(args[loc[1]:])
+	switch {
+	case strings.HasPrefix(strings.ToLower(when), "every "):
+		expr, err := scheduler.Every(when[len("every "):], time.Now())
+		if err != nil {
+			return nil, err
+		}
+		r.Schedule = expr
+	case strings.HasPrefix(strings.ToLower(when), "on "):
+		at, err := time.ParseInLocation(reminderTimeLayout, strings.TrimSpace(when[len("on "):]), time.Local)
+		if err != nil || !at.After(time.Now()) {
+			return nil, errInvalidReminder
+		}
+		r.At = at
+	default:
+		return nil, errInvalidReminder
+	}
+
+	return r, nil
+}
+
+// getReminderArgs returns the text typed after `remind<command>`, right after the bot mention or `leanmanager`, so
+// the word remind in the text of the reminder is never taken as the command
+func (m Message) getReminderArgs(command string) string {
+	fields := strings.SplitN(strings.TrimSpace(m.Text), " ", 2)
+	if len(fields) < 2 {
+		return ""
+	}
+	args := strings.TrimSpace(fields[1])
+	if !strings.HasPrefix(args, "remind"+command) {
+		return ""
+	}
+	return strings.TrimSpace(args[len("remind"+command):])
+}
+
+func (m Message) isAddReminderMsj(botMention string) bool {
+	return m.isReminderMsj(botMention, " ")
+}
+
+func (m Message) isListRemindersMsj(botMention string) bool {
+	return m.isReminderMsj(botMention, " list")
+}
+
+func (m Message) isDeleteReminderMsj(botMention string) bool {
+	return m.isReminderMsj(botMention, " delete")
+}
+
+func (m Message) isReminderMsj(botMention, command string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" remind"+command) ||
+		strings.HasPrefix(m.Text, "leanmanager remind"+command)) {
+		return true
+	}
+
+	return false
+}
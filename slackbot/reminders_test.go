@@ -0,0 +1,92 @@
+package slackbot
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+func TestGetValidReminder(t *testing.T) {
+	chat := NewSimulatorChat(strings.NewReader(""), &bytes.Buffer{}, "C1")
+	next := time.Now().AddDate(1, 0, 0)
+	y, mo, d := next.Date()
+	at := time.Date(y, mo, d, 9, 30, 0, 0, time.Local)
+	on := at.Format(reminderTimeLayout)
+
+	tests := []struct {
+		text     string
+		reminder api.Reminder
+	}{
+		{`leanmanager remind me "fill your hours" every friday 16:00`, api.Reminder{TargetMember: "U1",
+			Recipient: "@U1", Text: "fill your hours", Schedule: "0 16 * * 5"}},
+		{`@leanmanager remind here "release day!" on ` + on, api.Reminder{TargetChannel: "C1", Recipient: "here",
+			Text: "release day!", At: at}},
+		{`leanmanager remind @U2 "standup notes" every weekdays 9:00`, api.Reminder{TargetMember: "U2",
+			Recipient: "@U2", Text: "standup notes", Schedule: "0 9 * * 1-5"}},
+		{`leanmanager remind #C2 “retro tomorrow” on ` + on, api.Reminder{TargetChannel: "C2", Recipient: "#C2",
+			Text: "retro tomorrow", At: at}},
+		{`leanmanager remind me "remind here to remind me" every everyday 8:30`, api.Reminder{TargetMember: "U1",
+			Recipient: "@U1", Text: "remind here to remind me", Schedule: "30 8 * * *"}},
+		{`@reminderbot remind me "hours" every monday,thursday 10:30`, api.Reminder{TargetMember: "U1",
+			Recipient: "@U1", Text: "hours", Schedule: "30 10 * * 1,4"}},
+	}
+
+	for _, tt := range tests {
+		m := Message{Type: "message", User: "U1", Channel: "C1", Text: tt.text}
+		r, err := m.getValidReminder(chat)
+		if err != nil {
+			t.Errorf("getValidReminder(%q) failed: %v", tt.text, err)
+			continue
+		}
+		tt.reminder.ChannelID, tt.reminder.Author = "C1", "U1"
+		if !reflect.DeepEqual(*r, tt.reminder) {
+			t.Errorf("getValidReminder(%q) = %+v, want %+v", tt.text, *r, tt.reminder)
+		}
+	}
+}
+
+func TestGetValidReminderInvalid(t *testing.T) {
+	chat := NewSimulatorChat(strings.NewReader(""), &bytes.Buffer{}, "C1")
+
+	for _, text := range []string{
+		`leanmanager remind me fill your hours every friday 16:00`,
+		`leanmanager remind me "fill your hours every friday 16:00`,
+		`leanmanager remind "fill your hours" every friday 16:00`,
+		`leanmanager remind everybody "fill your hours" every friday 16:00`,
+		`leanmanager remind me "release day!" on 2017-03-21 09:00`,
+		`leanmanager remind me "release day!" on tomorrow`,
+		`leanmanager remind me "release day!"`,
+		`leanmanager remind me "release day!" at 9:00`,
+		`leanmanager remind me "release day!" every someday`,
+		`leanmanager hello, remind me "hours" every friday 16:00`,
+	} {
+		m := Message{Type: "message", User: "U1", Channel: "C1", Text: text}
+		if r, err := m.getValidReminder(chat); err == nil {
+			t.Errorf("getValidReminder(%q) = %+v, want an error", text, *r)
+		}
+	}
+}
+
+func TestGetReminderArgs(t *testing.T) {
+	tests := []struct {
+		text, command, args string
+	}{
+		{"leanmanager remind delete 3", " delete", "3"},
+		{"<@UBOT>   remind delete `3`", " delete", "`3`"},
+		{"leanmanager remind list", " list", ""},
+		{`leanmanager remind me "remind delete 3" every friday 16:00`, " delete", ""},
+		{`leanmanager remind me "remind delete 3" every friday 16:00`, "", `me "remind delete 3" every friday 16:00`},
+		{"leanmanager", "", ""},
+	}
+
+	for _, tt := range tests {
+		m := Message{Type: "message", Text: tt.text}
+		if got := m.getReminderArgs(tt.command); got != tt.args {
+			t.Errorf("getReminderArgs(%q) of %q = %q, want %q", tt.command, tt.text, got, tt.args)
+		}
+	}
+}
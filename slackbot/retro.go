@@ -0,0 +1,472 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+const (
+	// directChannel is where the members are awaited when they answer through direct messages
+	directChannel = "@direct"
+
+	// retroVotingTime is how long the Retrospective board accepts votes if the meeting doesn't set it
+	retroVotingTime = 10 * time.Minute
+
+	// maxActionItems is how many of the most voted action items are followed after a Retrospective
+	maxActionItems = 3
+)
+
+// retroVotesController counts the users reacting to each message of the open Retrospective boards
+type retroVotesController struct {
+	sync.Mutex
+	v map[string]map[string]bool
+}
+
+var retroVotes = retroVotesController{v: make(map[string]map[string]bool)}
+
+// open starts counting the votes of the message
+func (rv *retroVotesController) open(ts string) {
+	rv.Lock()
+	defer rv.Unlock()
+	rv.v[ts] = map[string]bool{}
+}
+
+// vote adds or removes the vote of the user, returning false if the message isn't open to votes
+func (rv *retroVotesController) vote(ts, userID string, add bool) bool {
+	rv.Lock()
+	defer rv.Unlock()
+	if rv.v[ts] == nil {
+		return false
+	}
+	if add {
+		rv.v[ts][userID] = true
+	} else {
+		delete(rv.v[ts], userID)
+	}
+	return true
+}
+
+// close stops counting the votes of the message and returns how many users voted it
+func (rv *retroVotesController) close(ts string) int {
+	rv.Lock()
+	defer rv.Unlock()
+	votes := len(rv.v[ts])
+	delete(rv.v, ts)
+	return votes
+}
+
+// runRetrospective asks each participant in private, posts the entries in a board and, once the voting is over,
+// follows the most voted action items
+func runRetrospective(chat ChatAdapter, mt api.Meeting, participants []string) {
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: mt.ChannelID,
+		Text: tr(mt.ChannelID, "Hi %s! Let's start the %s :mega: I'm asking each of you in private",
+			chat.MentionChannel(), meetingName(mt)),
+	}
+	if mt.Anonymous {
+		message.Text += tr(mt.ChannelID, ", nobody will know who wrote what :see_no_evil:")
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+	}
+
+	// Everybody answers at the same time, each one in their direct conversation
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		entries []api.RetroEntry
+	)
+	for _, p := range participants {
+		wg.Add(1)
+		go func(memberID string) {
+			defer wg.Done()
+			answers := askRetroByMember(chat, mt, memberID)
+			mu.Lock()
+			entries = append(entries, answers...)
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	if len(entries) == 0 {
+		message.Text = tr(mt.ChannelID, "Nobody answered the %s :disappointed:", meetingName(mt))
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+		}
+		return
+	}
+
+	// The order of the answers would tell who wrote them
+	if mt.Anonymous {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		r.Shuffle(len(entries), func(i, j int) { entries[i], entries[j] = entries[j], entries[i] })
+	}
+
+	retro := time.Now()
+	for i := range entries {
+		entries[i].Retro = retro
+		if mt.Anonymous {
+			entries[i].Author = ""
+		}
+		if err := addRetroEntry(&entries[i]); err != nil {
+			log.Printf("slackutils: error invoking API Server to store retro entry: %v", err)
+		}
+	}
+
+	voting := retroVotingTime
+	if mt.VotingTimeout > 0 {
+		voting = time.Duration(mt.VotingTimeout) * time.Second
+	}
+
+	board := postRetroBoard(chat, mt, entries, voting)
+	go closeRetroVoting(chat, mt, entries, board, voting)
+}
+
+// askRetroByMember asks the questions of the Retrospective in a direct conversation, one entry per line
+func askRetroByMember(chat ChatAdapter, mt api.Meeting, memberID string) []api.RetroEntry {
+
+	// Only one conversation at a time can be awaited in private
+	channelsMap.Lock()
+	if channelsMap.p[directChannel] == nil {
+		channelsMap.p[directChannel] = map[string]chan Message{}
+	}
+	if channelsMap.p[directChannel][memberID] != nil {
+		channelsMap.Unlock()
+		log.Printf("slackutils: member %s is already answering in private, not asked in the %s of channel %s",
+			memberID, mt.GetName(), mt.ChannelID)
+		return nil
+	}
+	channelsMap.p[directChannel][memberID] = make(chan Message)
+	channelsMap.Unlock()
+	defer channelsMap.finishWaitingMember(directChannel, memberID)
+
+	questions := mt.GetQuestions()
+	if len(questions) != len(api.RetroCategories) {
+		questions = api.DefaultQuestions[api.MeetingRetrospective]
+	}
+
+	_, limit := getDailyTimeouts(mt.ChannelID)
+
+	if err := chat.SendDirect(memberID, tr(mt.ChannelID, "Hi! It's time for the %s :thinking_face: Write one idea "+
+		"per line, or `none` if you have nothing to say", meetingName(mt))); err != nil {
+		log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+		return nil
+	}
+
+	var entries []api.RetroEntry
+	for i, category := range api.RetroCategories {
+		if err := chat.SendDirect(memberID, capitalize(tr(mt.ChannelID, questions[i]))); err != nil {
+			log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+			return entries
+		}
+
+		m, err := channelsMap.receiveInThread(chat, mt.ChannelID, directChannel, memberID, "", limit, nil)
+		if err != nil {
+			if err := chat.SendDirect(memberID, tr(mt.ChannelID, "Time's up! I keep what you wrote :hourglass:")); err != nil {
+				log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+			}
+			return entries
+		}
+
+		for _, line := range strings.Split(m.Text, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(line, "•-* "))
+			if line == "" || strings.EqualFold(line, "none") {
+				continue
+			}
+			entries = append(entries, api.RetroEntry{
+				ChannelID: mt.ChannelID,
+				MeetingID: mt.ID,
+				Category:  category,
+				Text:      line,
+				Author:    memberID,
+			})
+		}
+	}
+
+	if err := chat.SendDirect(memberID, tr(mt.ChannelID,
+		"Thanks! Vote your favourite ones in the channel :ballot_box_with_ballot:")); err != nil {
+		log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+	}
+	return entries
+}
+
+// postRetroBoard posts the entries grouped by category, one message each so they can be voted with reactions. It
+// returns the index of the entry posted in each message
+func postRetroBoard(chat ChatAdapter, mt api.Meeting, entries []api.RetroEntry, voting time.Duration) map[string]int {
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: mt.ChannelID,
+		Text: tr(mt.ChannelID, "*%s board* :clipboard: React to the items you like to vote them, "+
+			"the voting closes in %s", meetingName(mt), voting),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+	}
+
+	board := map[string]int{}
+	for _, category := range api.RetroCategories {
+		title := false
+		for i, e := range entries {
+			if e.Category != category {
+				continue
+			}
+
+			if !title {
+				title = true
+				message.Text = "*" + tr(mt.ChannelID, api.RetroCategoryNames[category]) + "*"
+				if err := message.send(chat); err != nil {
+					log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+				}
+			}
+
+			message.Text = "• " + e.Text
+			if e.Author != "" {
+				message.Text += " (" + chat.Mention(e.Author) + ")"
+			}
+			ts, err := message.sendWithAck(chat)
+			if err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+				continue
+			}
+			retroVotes.open(ts)
+			board[ts] = i
+		}
+	}
+
+	return board
+}
+
+// closeRetroVoting waits until the voting is over and turns the most voted action items into followed ones
+func closeRetroVoting(chat ChatAdapter, mt api.Meeting, entries []api.RetroEntry, board map[string]int,
+	voting time.Duration) {
+
+	time.Sleep(voting)
+
+	for ts, i := range board {
+		entries[i].Votes = retroVotes.close(ts)
+		if err := addRetroEntry(&entries[i]); err != nil {
+			log.Printf("slackutils: error invoking API Server to store retro entry: %v", err)
+		}
+	}
+
+	var actions []api.RetroEntry
+	for _, e := range entries {
+		if e.Category == api.RetroAction && e.Votes > 0 {
+			actions = append(actions, e)
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Votes > actions[j].Votes })
+	if len(actions) > maxActionItems {
+		actions = actions[:maxActionItems]
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: mt.ChannelID,
+		Text: tr(mt.ChannelID, "The voting is closed :ballot_box_with_ballot: No action item got votes, see you in "+
+			"the next %s", meetingName(mt)),
+	}
+
+	if len(actions) > 0 {
+		var b bytes.Buffer
+		b.WriteString(tr(mt.ChannelID, "The voting is closed :ballot_box_with_ballot: I'll remind these action items "+
+			"in the Daily Meeting:"))
+		for _, e := range actions {
+			// The member mentioned in the action is in charge of it, otherwise its author
+			item := api.ActionItem{
+				ChannelID: mt.ChannelID,
+				MeetingID: mt.ID,
+				Text:      e.Text,
+				Owner:     e.Author,
+				Votes:     e.Votes,
+				Created:   time.Now(),
+			}
+			if mentions := chat.ParseMentions(e.Text); len(mentions) > 0 {
+				item.Owner = mentions[0]
+			}
+
+			if err := addActionItem(&item); err != nil {
+				log.Printf("slackutils: error invoking API Server to store action item: %v", err)
+				_ = sendUnexpectedProblemMsj(chat, mt.ChannelID)
+				return
+			}
+			b.WriteString("\n" + formatActionItem(chat, item))
+		}
+		b.WriteString("\n" + tr(mt.ChannelID, "Type `@leanmanager action done <id>` once it's done"))
+		message.Text = b.String()
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", mt.ChannelID, err)
+	}
+}
+
+// remindActionItems tells the member their open action items, the ones of the whole team if memberID is empty
+func remindActionItems(chat ChatAdapter, channelID, memberID, threadTS string) {
+	items, err := listActionItems(channelID)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve action items of channel: %v", err)
+		return
+	}
+
+	var b bytes.Buffer
+	for _, item := range items {
+		if !item.Done && item.Owner == memberID {
+			b.WriteString("\n" + formatActionItem(chat, item))
+		}
+	}
+	if b.Len() == 0 {
+		return
+	}
+
+	message := &Message{
+		ID:       0,
+		Type:     "message",
+		Channel:  channelID,
+		Text:     tr(channelID, "Remember the open action items of the team :pushpin:") + b.String(),
+		ThreadTS: threadTS,
+	}
+	if memberID != "" {
+		message.Text = tr(channelID, "%s, remember your open action items :pushpin:", chat.Mention(memberID)) + b.String()
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+	}
+}
+
+func formatActionItem(chat ChatAdapter, item api.ActionItem) string {
+	text := "• `#" + strconv.Itoa(item.ID) + "` " + item.Text
+	if item.Owner != "" {
+		text += " (" + chat.Mention(item.Owner) + ")"
+	}
+	return text
+}
+
+func manageListActionItems(chat ChatAdapter, m *Message) {
+	items, err := listActionItems(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve action items of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	var b bytes.Buffer
+	for _, item := range items {
+		if !item.Done {
+			b.WriteString("\n" + formatActionItem(chat, item))
+		}
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), "There are no open action items :sunglasses:"),
+	}
+	if b.Len() > 0 {
+		message.Text = tr(m.getChannelID(), "Open action items:") + b.String()
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageDoneActionItem(chat ChatAdapter, m *Message) {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), ":scream: Type something like `@leanmanager action done 3`, see `action list`"),
+	}
+
+	fields := strings.Fields(m.Text[strings.Index(m.Text, "action done")+len("action done"):])
+	if len(fields) == 0 {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.Trim(fields[0], "`"), "#"))
+	if err != nil {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	items, err := listActionItems(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve action items of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	for _, item := range items {
+		if item.ID != id || item.Done {
+			continue
+		}
+
+		item.Done = true
+		if err := addActionItem(&item); err != nil {
+			log.Printf("slackutils: error invoking API Server to store action item: %v", err)
+			_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+			return
+		}
+		message.Text = tr(m.getChannelID(), "Well done! Action item `#%d` closed :white_check_mark:", id)
+		break
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// manageReaction counts the reactions to the Retrospective boards as votes, and the ones to the mood questions as
+// their answers
+func manageReaction(m *Message) {
+	if retroVotes.vote(m.Item.TS, m.User, m.Type == "reaction_added") {
+		log.Printf("slackbot: vote of %s in message %s of channel %s updated", m.User, m.Item.TS, m.getChannelID())
+		return
+	}
+
+	if m.Type == "reaction_added" {
+		moodQuestions.answer(m.Item.TS, m.User, m.Reaction)
+	}
+}
+
+func (m Message) isReactionMsj() bool {
+	return (m.Type == "reaction_added" || m.Type == "reaction_removed") && m.Item != nil && m.User != ""
+}
+
+func (m Message) isListActionItemsMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" action list") ||
+		strings.HasPrefix(m.Text, "leanmanager action list")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isDoneActionItemMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" action done") ||
+		strings.HasPrefix(m.Text, "leanmanager action done")) {
+		return true
+	}
+
+	return false
+}
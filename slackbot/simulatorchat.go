@@ -0,0 +1,279 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	simulatorBotID     = "leanmanager"
+	simulatorCreatorID = "U1"
+)
+
+// SimulatorChat is a fake chat backend fed by a terminal, useful to try the bot without Slack.
+// Each input line is posted in the channel:
+//
+//	as U123: text    posts text as the user U123
+//	text             posts text as the last user
+//	thread 1.000002  posts next lines inside the thread, `thread` alone leaves it
+//	join             the last user invites the bot to the channel and becomes its admin, `join alone` adds the bot
+//	                 without telling who invited it, so the creator of the channel, U1, is the admin
+//	leave U123       the user U123 leaves the channel
+//	away U123        the user U123 is marked as away, `back U123` connects them again
+//	dm U123: text    the user U123 sends text to the bot in a direct message
+//	react 1.000002   the last user reacts :+1: to the message, `react 1.000002 smile` with other emoji and
+//	                 `unreact 1.000002` removes the reaction
+//	quit             ends the simulation
+type SimulatorChat struct {
+	in        *bufio.Scanner
+	out       io.Writer
+	channelID string
+
+	sync.Mutex
+	user     string
+	threadTS string
+	ts       int
+	members  map[string]bool
+	away     map[string]bool
+	queued   []Message
+}
+
+// NewSimulatorChat returns a simulator reading users' messages from in and writing bot's messages to out
+func NewSimulatorChat(in io.Reader, out io.Writer, channelID string) *SimulatorChat {
+	return &SimulatorChat{
+		in:        bufio.NewScanner(in),
+		out:       out,
+		channelID: channelID,
+		user:      simulatorCreatorID,
+		members:   map[string]bool{simulatorBotID: true},
+		away:      map[string]bool{},
+	}
+}
+
+// Connect prints the usage, there is nothing to connect to
+func (sim *SimulatorChat) Connect() (string, error) {
+	fmt.Fprintf(sim.out, "Simulating channel %s, type `join` to invite the bot, `as U123: text` to talk "+
+		"as a user, `thread <ts>` to reply in a thread and `quit` to exit\n", sim.channelID)
+	return simulatorBotID, nil
+}
+
+// Receive reads the next line typed in the terminal
+func (sim *SimulatorChat) Receive() (Message, error) {
+	sim.Lock()
+	if len(sim.queued) > 0 {
+		m := sim.queued[0]
+		sim.queued = sim.queued[1:]
+		sim.Unlock()
+		return m, nil
+	}
+	sim.Unlock()
+
+	for sim.in.Scan() {
+		line := strings.TrimSpace(sim.in.Text())
+
+		sim.Lock()
+		switch {
+		case line == "":
+			sim.Unlock()
+			continue
+		case line == "quit":
+			sim.Unlock()
+			return Message{}, ErrChatClosed
+		case line == "join" || line == "join alone":
+			// As Slack, who invited the bot comes in its member_joined_channel event
+			if line == "join" {
+				sim.queued = append(sim.queued, Message{
+					Type:    "member_joined_channel",
+					User:    simulatorBotID,
+					Channel: sim.channelID,
+					Inviter: sim.user,
+				})
+			}
+			sim.Unlock()
+			return Message{
+				Type:    "channel_joined",
+				Channel: map[string]interface{}{"id": sim.channelID},
+			}, nil
+		case strings.HasPrefix(line, "leave "):
+			userID := strings.TrimSpace(strings.TrimPrefix(line, "leave "))
+			delete(sim.members, userID)
+			sim.Unlock()
+			return Message{
+				Type:    "member_left_channel",
+				User:    userID,
+				Channel: sim.channelID,
+			}, nil
+		case strings.HasPrefix(line, "away "):
+			userID := strings.TrimSpace(strings.TrimPrefix(line, "away "))
+			sim.away[userID] = true
+			sim.Unlock()
+			return Message{Type: "presence_change", User: userID, Presence: "away"}, nil
+		case strings.HasPrefix(line, "back "):
+			userID := strings.TrimSpace(strings.TrimPrefix(line, "back "))
+			delete(sim.away, userID)
+			sim.Unlock()
+			return Message{Type: "presence_change", User: userID, Presence: "active"}, nil
+		case strings.HasPrefix(line, "dm ") && strings.Contains(line, ":"):
+			i := strings.Index(line, ":")
+			userID := strings.TrimSpace(line[len("dm "):i])
+			m := Message{
+				Type:    "message",
+				User:    userID,
+				Channel: "D" + userID,
+				Text:    strings.TrimSpace(line[i+1:]),
+				TS:      sim.nextTS(),
+				Direct:  true,
+			}
+			sim.Unlock()
+			return m, nil
+		case strings.HasPrefix(line, "react ") || strings.HasPrefix(line, "unreact "):
+			typ := "reaction_added"
+			if strings.HasPrefix(line, "unreact ") {
+				typ = "reaction_removed"
+			}
+			args := append(strings.Fields(line)[1:], "+1")
+			m := Message{
+				Type:     typ,
+				User:     sim.user,
+				Reaction: strings.Trim(args[1], ":"),
+				Item: &MessageItem{
+					Type:    "message",
+					Channel: sim.channelID,
+					TS:      args[0],
+				},
+			}
+			sim.Unlock()
+			return m, nil
+		case line == "thread" || strings.HasPrefix(line, "thread "):
+			sim.threadTS = strings.TrimSpace(strings.TrimPrefix(line, "thread"))
+			sim.Unlock()
+			continue
+		case strings.HasPrefix(line, "as ") && strings.Contains(line, ":"):
+			i := strings.Index(line, ":")
+			sim.user = strings.TrimSpace(line[len("as "):i])
+			line = strings.TrimSpace(line[i+1:])
+		}
+
+		sim.members[sim.user] = true
+		m := Message{
+			Type:     "message",
+			User:     sim.user,
+			Channel:  sim.channelID,
+			Text:     line,
+			TS:       sim.nextTS(),
+			ThreadTS: sim.threadTS,
+		}
+		sim.Unlock()
+		return m, nil
+	}
+
+	if err := sim.in.Err(); err != nil {
+		return Message{}, err
+	}
+	return Message{}, ErrChatClosed
+}
+
+// Send prints the bot's message
+func (sim *SimulatorChat) Send(m Message) error {
+	_, err := sim.SendWithAck(m)
+	return err
+}
+
+// SendWithAck prints the bot's message and returns its ts, which can be used as thread
+func (sim *SimulatorChat) SendWithAck(m Message) (string, error) {
+	sim.Lock()
+	defer sim.Unlock()
+
+	ts := sim.nextTS()
+	where := fmt.Sprintf("%v", m.Channel)
+	if m.ThreadTS != "" {
+		where += " > " + m.ThreadTS
+	}
+	name := simulatorBotID
+	if m.Username != "" {
+		name = m.Username
+	}
+	if m.Icon != "" {
+		name += " " + m.Icon
+	}
+	_, err := fmt.Fprintf(sim.out, "[%s %s] %s: %s\n", where, ts, name, m.Text)
+	return ts, err
+}
+
+// SendDirect prints the bot's private message to the user
+func (sim *SimulatorChat) SendDirect(userID, text string) error {
+	sim.Lock()
+	defer sim.Unlock()
+
+	_, err := fmt.Fprintf(sim.out, "[DM %s %s] %s: %s\n", userID, sim.nextTS(), simulatorBotID, text)
+	return err
+}
+
+// Mention formats the user as @U123
+func (sim *SimulatorChat) Mention(userID string) string {
+	return "@" + userID
+}
+
+// MentionChannel is printed as @channel
+func (sim *SimulatorChat) MentionChannel() string {
+	return "@channel"
+}
+
+// ParseMentions returns the IDs of all the @U123 mentions in the text
+func (sim *SimulatorChat) ParseMentions(text string) (userIDs []string) {
+	re := regexp.MustCompile("[@]([A-Za-z0-9]+)")
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		userIDs = append(userIDs, match[1])
+	}
+	return userIDs
+}
+
+// ParseChannels returns the IDs of all the #C123 mentions in the text
+func (sim *SimulatorChat) ParseChannels(text string) (channelIDs []string) {
+	re := regexp.MustCompile("[#]([A-Za-z0-9]+)")
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		channelIDs = append(channelIDs, match[1])
+	}
+	return channelIDs
+}
+
+// GetUser returns a profile named as the user ID
+func (sim *SimulatorChat) GetUser(userID string) (ChatUser, error) {
+	return ChatUser{
+		ID:    userID,
+		Name:  userID,
+		IsBot: userID == simulatorBotID,
+	}, nil
+}
+
+// ChannelMembers returns the bot and the users who have talked in the channel
+func (sim *SimulatorChat) ChannelMembers(channelID string) (members []string, err error) {
+	sim.Lock()
+	defer sim.Unlock()
+	for u := range sim.members {
+		members = append(members, u)
+	}
+	return members, nil
+}
+
+// ChannelCreator returns U1, the user talking when the simulation starts
+func (sim *SimulatorChat) ChannelCreator(channelID string) (string, error) {
+	return simulatorCreatorID, nil
+}
+
+func (sim *SimulatorChat) nextTS() string {
+	sim.ts++
+	return fmt.Sprintf("1.%06d", sim.ts)
+}
+
+// IsAway returns true if the user was marked with `away`
+func (sim *SimulatorChat) IsAway(userID string) (bool, error) {
+	sim.Lock()
+	defer sim.Unlock()
+	return sim.away[userID], nil
+}
@@ -3,6 +3,7 @@ package slackbot
 
 import (
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -10,31 +11,50 @@ import (
 	"time"
 
 	"github.com/antonmry/leanmanager/api"
-
-	"golang.org/x/net/websocket"
+	"github.com/antonmry/leanmanager/scheduler"
 )
 
 var (
 	teamID       string
 	apiserverURL string
+	apiClient    = &http.Client{}
 )
 
 // LaunchSlackbot starts the Slackbot connecting to Slack and starting to process messages
-func LaunchSlackbot(slackTokenArg, teamIDArg, apiserverHostArg string, apiserverPortArg int) {
+func LaunchSlackbot(slackTokenArg, teamIDArg, apiserverHostArg string, apiserverPortArg int, apiKeyArg string) {
+	launchBot(NewSlackChat(slackTokenArg), teamIDArg, apiserverHostArg, apiserverPortArg, apiKeyArg)
+}
+
+// LaunchMattermostbot starts the bot connecting to a Mattermost server and starting to process messages
+func LaunchMattermostbot(mattermostURLArg, mattermostTokenArg, teamIDArg, apiserverHostArg string,
+	apiserverPortArg int, apiKeyArg string) {
+	launchBot(NewMattermostChat(mattermostURLArg, mattermostTokenArg), teamIDArg, apiserverHostArg,
+		apiserverPortArg, apiKeyArg)
+}
+
+// LaunchSimulator starts the bot against a fake chat where users' messages are read from in and
+// bot's messages written to out, it returns when the input ends
+func LaunchSimulator(in io.Reader, out io.Writer, channelIDArg, teamIDArg, apiserverHostArg string,
+	apiserverPortArg int, apiKeyArg string) {
+	launchBot(NewSimulatorChat(in, out, channelIDArg), teamIDArg, apiserverHostArg, apiserverPortArg, apiKeyArg)
+}
+
+func launchBot(chat ChatAdapter, teamIDArg, apiserverHostArg string, apiserverPortArg int, apiKeyArg string) {
 
 	// Global variables
 	teamID = teamIDArg
 	apiserverURL = "http://" + apiserverHostArg + ":" + strconv.Itoa(apiserverPortArg)
+	apiClient = &http.Client{Transport: &apiKeyTransport{key: apiKeyArg}}
 
-	// Open connection with Slack
-	ws, botID, err := slackConnect(slackTokenArg)
+	// Open connection with the chat
+	botID, err := chat.Connect()
 	if err != nil {
-		log.Fatalf("Error connecting to Slack, check your token and Internet connection: %v", err)
+		log.Fatalf("Error connecting to the chat, check your token and Internet connection: %v", err)
 	}
 
 	log.Println("slackbot: bot connected")
 
-	resp, err := http.Get(apiserverURL + "/dailymeetings/" + teamID)
+	resp, err := apiClient.Get(apiserverURL + "/dailymeetings/" + teamID)
 	defer resp.Body.Close()
 
 	if err != nil || resp.StatusCode != 200 {
@@ -53,124 +73,239 @@ func LaunchSlackbot(slackTokenArg, teamIDArg, apiserverHostArg string, apiserver
 	channelsDailyMap.Lock()
 	for _, t := range teamDailyMeetings {
 		// TODO: key should be a boolean, not ChannelID
-		channelsDailyMap.d[t.ChannelID] = api.DailyMeeting{
-			ChannelID: t.ChannelID,
-			LastDaily: t.LastDaily,
-			StartTime: t.StartTime,
-			LimitTime: t.LimitTime,
-			Days:      t.Days,
-		}
+		channelsDailyMap.set(t.ChannelID, t)
 	}
 	channelsDailyMap.Unlock()
 
+	// Dailies interrupted by a restart go on before the scheduled ones are launched
+	restoreDailies(chat)
+
 	// Scheduled tasks (daily launching)
 	t := time.NewTicker(60 * time.Second)
 	go func() {
 		for {
-			launchScheduledTasks(ws)
+			launchScheduledTasks(chat)
+			launchScheduledMeetings(chat)
+			launchScheduledReminders(chat)
+			launchScheduledTimesheets(chat)
+			launchScheduledMornings(chat)
 			<-t.C
 		}
 	}()
 
 	// Message processing
 	for {
-		if m, err := receiveMessage(ws); err != nil {
+		m, err := chat.Receive()
+		if err == nil {
+			activity.observe(m)
+		}
+
+		if err == ErrChatClosed {
+			log.Println("slackbot: chat closed")
+			return
+		} else if err != nil {
 			log.Printf("slackbot: error receiving message: %v", err)
-			ws, botID, err = slackConnect(slackTokenArg)
+			botID, err = chat.Connect()
 			if err != nil {
-				log.Printf("slackbot: error reconnecting to Slack: %v", err)
+				log.Printf("slackbot: error reconnecting to the chat: %v", err)
 
 			}
 			continue
 		} else {
-			go func(m Message) {
-				manageMessage(m, botID, ws)
-			}(m)
+			go func(m Message, botID string) {
+				manageMessage(m, botID, chat)
+			}(m, botID)
 		}
 	}
 }
 
-func launchScheduledTasks(ws *websocket.Conn) {
+// missedDailyGrace is how late a Daily Meeting still starts, e.g. if the bot was down at its time
+const missedDailyGrace = 2 * time.Hour
 
-	channelsDailyMap.Lock()
-	defer channelsDailyMap.Unlock()
+func launchScheduledTasks(chat ChatAdapter) {
+
+	t := time.Now()
 
+	// Pending dailies are collected first, checking the team's activity needs the API Server and the chat
+	var pending []api.DailyMeeting
+	channelsDailyMap.Lock()
 	for _, v := range channelsDailyMap.d {
-		t := time.Now()
-		// Firt, check there are than 12 hours since the last one
-		if !v.LastDaily.IsZero() && v.LastDaily.Sub(t).Hours() < 12 {
+		s, err := scheduler.ForDaily(v)
+		if err == scheduler.ErrNotScheduled {
+			continue
+		} else if err != nil {
+			log.Printf("slackbot: invalid schedule in channel %s: %v", v.ChannelID, err)
 			continue
 		}
 
-		// Then check if today is a daily meeting day
-		found := false
-		for _, d := range v.Days {
-			if d == t.Weekday() {
-				found = true
-			}
+		// Each occurrence runs once: only the ones after the last Daily Meeting and not too old
+		since := t.Add(-missedDailyGrace - flexibleWindow(v))
+		if v.LastDaily.After(since) {
+			since = v.LastDaily
 		}
 
-		if !found {
+		occurrence := scheduler.Pending(s, since, t)
+		if occurrence.IsZero() {
 			continue
 		}
 
-		// Check if it's time to start the meeting
-		tReference := time.Date(0, 1, 1, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
-		if v.StartTime.Sub(tReference) > 0 {
+		// Flexible dailies wait for the team until the limit time
+		if window := flexibleWindow(v); window > 0 && t.Before(occurrence.Add(window)) {
+			pending = append(pending, v)
 			continue
 		}
 
-		teamAvailability := true
+		launchDaily(chat, v, t)
+	}
+	channelsDailyMap.Unlock()
 
-		if !v.LimitTime.IsZero() && v.LimitTime.Sub(tReference) > 0 && !teamAvailability {
+	for _, v := range pending {
+		if !isTeamActive(chat, v) {
 			continue
 		}
 
-		m := Message{
-			ID:      0,
-			Type:    "message",
-			Channel: v.ChannelID,
-			Text:    "",
+		channelsDailyMap.Lock()
+		if d, ok := channelsDailyMap.d[v.ChannelID]; ok && d.LastDaily.Equal(v.LastDaily) {
+			launchDaily(chat, d, t)
 		}
-		go func(m Message) {
-			manageStartDaily(ws, &m)
-		}(m)
+		channelsDailyMap.Unlock()
+	}
+}
+
+// flexibleWindow returns how long the Daily Meeting can wait for the team, zero if it isn't flexible
+func flexibleWindow(d api.DailyMeeting) time.Duration {
+	if d.Schedule != "" || d.LimitTime.IsZero() || !d.LimitTime.After(d.StartTime) {
+		return 0
 	}
+	return d.LimitTime.Sub(d.StartTime)
 }
 
-func manageMessage(m Message, botID string, ws *websocket.Conn) {
+// launchDaily marks the occurrence as done and starts the Daily Meeting, channelsDailyMap must be locked
+func launchDaily(chat ChatAdapter, v api.DailyMeeting, t time.Time) {
+	v.LastDaily = t
+	channelsDailyMap.set(v.ChannelID, v)
+
+	m := Message{
+		ID:      0,
+		Type:    "message",
+		Channel: v.ChannelID,
+		Text:    "",
+	}
+	go func(m Message) {
+		manageStartDaily(chat, &m)
+	}(m)
+}
+
+func manageMessage(m Message, botID string, chat ChatAdapter) {
 
 	if m.getChannelID() == "" {
 		return
 	}
 
+	botMention := chat.Mention(botID)
+
 	switch {
-	case m.isInitialMsj(botID):
-		manageHello(ws, &m)
-	case m.isAddMemberDailyMsj(botID):
-		manageAddMember(ws, &m)
-	case m.isDeleteMemberDailyMsj(botID):
-		manageDelMember(ws, &m)
-	case m.isListMembersDailyMsj(botID):
-		manageListMembers(ws, &m)
-	case m.isStartDailyMsj(botID):
-		manageStartDaily(ws, &m)
-	case m.isResumeDailyMsj(botID):
-		manageResumeDaily(ws, &m)
-	case m.isInfoDailyMsj(botID):
-		manageInfoDaily(ws, &m)
-	case m.isScheduleDailyMsj(botID):
-		manageScheduleDaily(ws, &m)
-	case m.isAddReplyDailyMsj(botID):
-		manageAddReplyDaily(ws, &m)
-	case m.isDeleteReplyDailyMsj(botID):
-		manageDeleteReplyDaily(ws, &m)
-	case m.isHelpMsj(botID):
-		manageHelp(ws, &m)
-	case m.isCommand(botID):
-		manageUnderstoodCommand(ws, &m)
+	case m.isInitialMsj(botMention):
+		manageHello(chat, &m)
+	case m.isBotJoinedMsj(botID):
+		manageBotJoined(chat, &m)
+	case m.isMemberLeftMsj():
+		manageMemberLeft(chat, &m)
+	case m.isAddAllMembersDailyMsj(botMention):
+		manageAddAllMembers(chat, &m)
+	case m.isAddMemberDailyMsj(botMention):
+		manageAddMember(chat, &m)
+	case m.isDeleteMemberDailyMsj(botMention):
+		manageDelMember(chat, &m)
+	case m.isListMembersDailyMsj(botMention):
+		manageListMembers(chat, &m)
+	case m.isStartDailyMsj(botMention):
+		manageStartDaily(chat, &m)
+	case m.isResumeDailyMsj(botMention):
+		manageResumeDaily(chat, &m)
+	case m.isPendingDailyMsj(botMention):
+		managePendingDaily(chat, &m)
+	case m.isSkipDailyMsj(botMention):
+		manageSkipDaily(chat, &m)
+	case m.isSnoozeDailyMsj(botMention):
+		manageSnoozeDaily(chat, &m)
+	case m.isStopDailyMsj(botMention):
+		manageStopDaily(chat, &m)
+	case m.isEndDailyMsj(botMention):
+		manageEndDaily(chat, &m)
+	case m.isInfoDailyMsj(botMention):
+		manageInfoDaily(chat, &m)
+	case m.isScheduleDailyMsj(botMention):
+		manageScheduleDaily(chat, &m)
+	case m.isMoodReportDailyMsj(botMention):
+		manageMoodReportDaily(chat, &m)
+	case m.isMoodDailyMsj(botMention):
+		manageMoodDaily(chat, &m)
+	case m.isThreadedDailyMsj(botMention):
+		manageThreadedDaily(chat, &m)
+	case m.isPersonaDailyMsj(botMention):
+		managePersonaDaily(chat, &m)
+	case m.isLanguageDailyMsj(botMention):
+		manageLanguageDaily(chat, &m)
+	case m.isContentDailyMsj(botMention):
+		manageContentDaily(chat, &m)
+	case m.isTimeoutDailyMsj(botMention):
+		manageTimeoutDaily(chat, &m)
+	case m.isOrderDailyMsj(botMention):
+		manageOrderDaily(chat, &m)
+	case m.isRoleDailyMsj(botMention):
+		manageRoleDaily(chat, &m)
+	case m.isAddMeetingMsj(botMention):
+		manageAddMeeting(chat, &m)
+	case m.isListMeetingsMsj(botMention):
+		manageListMeetings(chat, &m)
+	case m.isDeleteMeetingMsj(botMention):
+		manageDeleteMeeting(chat, &m)
+	case m.isStartMeetingMsj(botMention):
+		manageStartMeeting(chat, &m)
+	case m.isListActionItemsMsj(botMention):
+		manageListActionItems(chat, &m)
+	case m.isDoneActionItemMsj(botMention):
+		manageDoneActionItem(chat, &m)
+	case m.isScheduleTimesheetMsj(botMention):
+		manageScheduleTimesheet(chat, &m)
+	case m.isHoursTimesheetMsj(botMention):
+		manageHoursTimesheet(chat, &m)
+	case m.isFillTimesheetMsj(botMention):
+		manageFillTimesheet(chat, &m)
+	case m.isReportTimesheetMsj(botMention):
+		manageReportTimesheet(chat, &m)
+	case m.isScheduleMorningMsj(botMention):
+		manageScheduleMorning(chat, &m)
+	case m.isMorningMsj(botMention):
+		manageMorning(chat, &m)
+	case m.isListAbsencesMsj(botMention):
+		manageListAbsences(chat, &m)
+	case m.isDeleteAbsenceMsj(botMention):
+		manageDeleteAbsence(chat, &m)
+	case m.isAddAbsenceMsj(botMention):
+		manageAddAbsence(chat, &m)
+	case m.isListRemindersMsj(botMention):
+		manageListReminders(chat, &m)
+	case m.isDeleteReminderMsj(botMention):
+		manageDeleteReminder(chat, &m)
+	case m.isAddReminderMsj(botMention):
+		manageAddReminder(chat, &m)
+	case m.isReactionMsj():
+		manageReaction(&m)
+	case m.isAddReplyDailyMsj(botMention):
+		manageAddReplyDaily(chat, &m)
+	case m.isDeleteReplyDailyMsj(botMention):
+		manageDeleteReplyDaily(chat, &m)
+	case m.isHelpMsj(botMention):
+		manageHelp(chat, &m)
+	case m.isCommand(botMention):
+		if manageIntent(chat, m, botID) {
+			return
+		}
+		manageUnderstoodCommand(chat, &m, botMention)
 		log.Printf("slackbot: bot %s has received an understood message", botID)
-	case isExpectedMessage(&m):
-		manageExpectedMessage(ws, &m)
+	case isExpectedMessage(chat, &m):
+		manageExpectedMessage(chat, &m)
 	}
 }
@@ -0,0 +1,465 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+type responseRtmStart struct {
+	Ok    bool         `json:"ok"`
+	Error string       `json:"error"`
+	URL   string       `json:"url"`
+	Self  responseSelf `json:"self"`
+}
+
+type responseSelf struct {
+	ID string `json:"id"`
+}
+
+type responseUsersGetPresence struct {
+	Ok       bool   `json:"ok"`
+	Error    string `json:"error"`
+	Presence string `json:"presence"`
+}
+
+type responseConversationsOpen struct {
+	Ok      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+}
+
+type responseConversationsInfo struct {
+	Ok      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Channel struct {
+		Creator string `json:"creator"`
+	} `json:"channel"`
+}
+
+type responsePostMessage struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+	TS    string `json:"ts"`
+}
+
+type responseUsersInfo struct {
+	Ok    bool              `json:"ok"`
+	Error string            `json:"error"`
+	User  responseSlackUser `json:"user"`
+}
+
+type responseSlackUser struct {
+	ID       string               `json:"id"`
+	Name     string               `json:"name"`
+	RealName string               `json:"real_name"`
+	TZ       string               `json:"tz"`
+	IsBot    bool                 `json:"is_bot"`
+	Profile  responseSlackProfile `json:"profile"`
+}
+
+type responseSlackProfile struct {
+	DisplayName string `json:"display_name"`
+	RealName    string `json:"real_name"`
+	Image72     string `json:"image_72"`
+}
+
+type responseConversationsMembers struct {
+	Ok       bool     `json:"ok"`
+	Error    string   `json:"error"`
+	Members  []string `json:"members"`
+	Metadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+type ackController struct {
+	sync.Mutex
+	a map[uint64]chan Message
+}
+
+// SlackChat connects the bot to Slack using the Real Time API
+type SlackChat struct {
+	token string
+	acks  ackController
+
+	sync.RWMutex
+	ws *websocket.Conn
+}
+
+// NewSlackChat returns a Slack adapter which uses the bot token to connect
+func NewSlackChat(token string) *SlackChat {
+	return &SlackChat{
+		token: token,
+		acks:  ackController{a: make(map[uint64]chan Message)},
+	}
+}
+
+// Connect opens the websocket with the Slack RTM API
+func (s *SlackChat) Connect() (string, error) {
+	ws, botID, err := slackConnect(s.token)
+	if err != nil {
+		return "", err
+	}
+
+	s.Lock()
+	s.ws = ws
+	s.Unlock()
+	return botID, nil
+}
+
+// Receive returns the next Slack event, acks of sent messages are consumed here
+func (s *SlackChat) Receive() (m Message, err error) {
+	for {
+		m = Message{}
+		if err = websocket.JSON.Receive(s.conn(), &m); err != nil {
+			return
+		}
+		if !m.isAck() {
+			// Direct conversations have their own channels, their IDs start with D
+			if ch, ok := m.Channel.(string); ok && m.Type == "message" && strings.HasPrefix(ch, "D") {
+				m.Direct = true
+			}
+			return
+		}
+		s.acks.ack(m)
+	}
+}
+
+// Send posts the message through the websocket, or with the Web API if it's signed with a persona, as the RTM API
+// can't change the name or the icon of the bot
+func (s *SlackChat) Send(m Message) error {
+	if m.Username != "" || m.Icon != "" {
+		_, err := s.postMessage(m)
+		return err
+	}
+	return websocket.JSON.Send(s.conn(), m)
+}
+
+// SendWithAck posts the message and waits until Slack confirms it, returning the ts assigned to it
+func (s *SlackChat) SendWithAck(m Message) (string, error) {
+	if m.Username != "" || m.Icon != "" {
+		return s.postMessage(m)
+	}
+
+	ack := make(chan Message, 1)
+	s.acks.Lock()
+	s.acks.a[m.ID] = ack
+	s.acks.Unlock()
+	defer s.acks.finishWaitingAck(m.ID)
+
+	if err := s.Send(m); err != nil {
+		return "", err
+	}
+
+	select {
+	case r := <-ack:
+		return r.TS, nil
+	case <-time.After(time.Second * time.Duration(timeout)):
+		return "", fmt.Errorf("slackchat: no ack received for message %d", m.ID)
+	}
+}
+
+// postMessage posts the message with chat.postMessage, it needs the chat:write.customize scope to show the persona
+func (s *SlackChat) postMessage(m Message) (string, error) {
+	channelID, _ := m.Channel.(string)
+	v := url.Values{"token": {s.token}, "channel": {channelID}, "text": {m.Text}}
+	if m.ThreadTS != "" {
+		v.Set("thread_ts", m.ThreadTS)
+	}
+	if m.Username != "" {
+		v.Set("username", m.Username)
+	}
+	if strings.HasPrefix(m.Icon, ":") {
+		v.Set("icon_emoji", m.Icon)
+	} else if m.Icon != "" {
+		v.Set("icon_url", m.Icon)
+	}
+
+	resp, err := http.PostForm("https://slack.com/api/chat.postMessage", v)
+	if err != nil {
+		return "", fmt.Errorf("slackchat: error Post: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("slackchat: error reading Body: %s", err)
+	}
+
+	var slackResp responsePostMessage
+	if err := json.Unmarshal(body, &slackResp); err != nil {
+		return "", fmt.Errorf("slackchat: error parsing Slack resp: %s", err)
+	}
+
+	if !slackResp.Ok {
+		return "", fmt.Errorf("slackchat: error posting message in channel %s: %s", channelID, slackResp.Error)
+	}
+	return slackResp.TS, nil
+}
+
+// SendDirect opens the direct conversation with the user and posts the text there
+func (s *SlackChat) SendDirect(userID, text string) error {
+	resp, err := http.PostForm("https://slack.com/api/conversations.open",
+		url.Values{"token": {s.token}, "users": {userID}})
+	if err != nil {
+		return fmt.Errorf("slackchat: error Post: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("slackchat: error reading Body: %s", err)
+	}
+
+	var slackResp responseConversationsOpen
+	if err := json.Unmarshal(body, &slackResp); err != nil {
+		return fmt.Errorf("slackchat: error parsing Slack resp: %s", err)
+	}
+
+	if !slackResp.Ok {
+		return fmt.Errorf("slackchat: error opening conversation with %s: %s", userID, slackResp.Error)
+	}
+
+	return s.Send(Message{
+		ID:      counter.add(1),
+		Type:    "message",
+		Channel: slackResp.Channel.ID,
+		Text:    text,
+	})
+}
+
+// Mention formats the user ID as Slack does
+func (s *SlackChat) Mention(userID string) string {
+	return "<@" + userID + ">"
+}
+
+// MentionChannel notifies the members of the channel, a plain @channel isn't a broadcast in Slack
+func (s *SlackChat) MentionChannel() string {
+	return "<!channel>"
+}
+
+// ParseMentions returns the IDs of all the <@USER> mentions in the text
+func (s *SlackChat) ParseMentions(text string) (userIDs []string) {
+	re := regexp.MustCompile("(?i)[<][@]([A-Za-z0-9]+)([|][^>]*)?[>]")
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		userIDs = append(userIDs, match[1])
+	}
+	return userIDs
+}
+
+// ParseChannels returns the IDs of all the <#CHANNEL> mentions in the text
+func (s *SlackChat) ParseChannels(text string) (channelIDs []string) {
+	re := regexp.MustCompile("[<][#]([A-Za-z0-9]+)([|][^>]*)?[>]")
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		channelIDs = append(channelIDs, match[1])
+	}
+	return channelIDs
+}
+
+// GetUser retrieves the user's profile with users.info
+func (s *SlackChat) GetUser(userID string) (ChatUser, error) {
+	resp, err := http.Get("https://slack.com/api/users.info?token=" + url.QueryEscape(s.token) +
+		"&user=" + url.QueryEscape(userID))
+	if err != nil {
+		return ChatUser{}, fmt.Errorf("slackchat: error Get: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return ChatUser{}, fmt.Errorf("slackchat: error reading Body: %s", err)
+	}
+
+	var slackResp responseUsersInfo
+	if err := json.Unmarshal(body, &slackResp); err != nil {
+		return ChatUser{}, fmt.Errorf("slackchat: error parsing Slack resp: %s", err)
+	}
+
+	if !slackResp.Ok {
+		return ChatUser{}, fmt.Errorf("slackchat: error retrieving user %s: %s", userID, slackResp.Error)
+	}
+
+	user := ChatUser{
+		ID:       slackResp.User.ID,
+		Name:     slackResp.User.Profile.DisplayName,
+		RealName: slackResp.User.RealName,
+		TimeZone: slackResp.User.TZ,
+		Avatar:   slackResp.User.Profile.Image72,
+		IsBot:    slackResp.User.IsBot || slackResp.User.ID == "USLACKBOT",
+	}
+	if user.Name == "" {
+		user.Name = slackResp.User.Name
+	}
+	if user.RealName == "" {
+		user.RealName = slackResp.User.Profile.RealName
+	}
+	return user, nil
+}
+
+// ChannelMembers retrieves all the pages of conversations.members
+func (s *SlackChat) ChannelMembers(channelID string) (members []string, err error) {
+	cursor := ""
+	for {
+		resp, err := http.Get("https://slack.com/api/conversations.members?token=" + url.QueryEscape(s.token) +
+			"&channel=" + url.QueryEscape(channelID) + "&limit=200&cursor=" + url.QueryEscape(cursor))
+		if err != nil {
+			return nil, fmt.Errorf("slackchat: error Get: %s", err)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("slackchat: error reading Body: %s", err)
+		}
+
+		var slackResp responseConversationsMembers
+		if err := json.Unmarshal(body, &slackResp); err != nil {
+			return nil, fmt.Errorf("slackchat: error parsing Slack resp: %s", err)
+		}
+
+		if !slackResp.Ok {
+			return nil, fmt.Errorf("slackchat: error retrieving members of channel %s: %s", channelID,
+				slackResp.Error)
+		}
+
+		members = append(members, slackResp.Members...)
+		cursor = slackResp.Metadata.NextCursor
+		if cursor == "" {
+			return members, nil
+		}
+	}
+}
+
+// ChannelCreator retrieves the creator of the channel with conversations.info
+func (s *SlackChat) ChannelCreator(channelID string) (string, error) {
+	resp, err := http.Get("https://slack.com/api/conversations.info?token=" + url.QueryEscape(s.token) +
+		"&channel=" + url.QueryEscape(channelID))
+	if err != nil {
+		return "", fmt.Errorf("slackchat: error Get: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("slackchat: error reading Body: %s", err)
+	}
+
+	var slackResp responseConversationsInfo
+	if err := json.Unmarshal(body, &slackResp); err != nil {
+		return "", fmt.Errorf("slackchat: error parsing Slack resp: %s", err)
+	}
+
+	if !slackResp.Ok {
+		return "", fmt.Errorf("slackchat: error retrieving channel %s: %s", channelID, slackResp.Error)
+	}
+	return slackResp.Channel.Creator, nil
+}
+
+func (s *SlackChat) conn() *websocket.Conn {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ws
+}
+
+// Connection methods
+
+func slackConnect(token string) (ws *websocket.Conn, botID string, err error) {
+	wsURL, botID, err := slackInit(token)
+	if err != nil {
+		return nil, "", fmt.Errorf("slackchat: error initiating communication with slack: %s", err)
+	}
+
+	ws, err = websocket.Dial(wsURL, "", "https://api.slack.com/")
+	if err != nil {
+		return nil, "", fmt.Errorf("slackchat: error creating websocket: %s", err)
+	}
+
+	return ws, botID, nil
+}
+
+func slackInit(token string) (wsurl, id string, err error) {
+	url := fmt.Sprintf("https://slack.com/api/rtm.start?token=%s", token)
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", "", fmt.Errorf("slackchat: error Get: %s", err)
+	}
+	if resp.StatusCode != 200 {
+		return "", "", fmt.Errorf("slackchat: error response from Get: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", "", fmt.Errorf("slackchat: error reading Body: %s", err)
+	}
+
+	var slackResp responseRtmStart
+	err = json.Unmarshal(body, &slackResp)
+	if err != nil {
+		return "", "", fmt.Errorf("slackchat: error parsing Slack resp: %s", err)
+	}
+
+	if !slackResp.Ok {
+		return "", "", fmt.Errorf("slackchat: error returning ko: %s", err)
+	}
+
+	wsurl = slackResp.URL
+	id = slackResp.Self.ID
+	return
+}
+
+func (m Message) isAck() bool {
+	return m.Type == "" && m.ReplyTo != 0
+}
+
+func (ac *ackController) ack(m Message) {
+	ac.Lock()
+	defer ac.Unlock()
+	if c, ok := ac.a[m.ReplyTo]; ok {
+		c <- m
+	}
+}
+
+func (ac *ackController) finishWaitingAck(id uint64) {
+	ac.Lock()
+	delete(ac.a, id)
+	ac.Unlock()
+}
+
+// IsAway asks users.getPresence, which answers active or away
+func (s *SlackChat) IsAway(userID string) (bool, error) {
+	resp, err := http.Get("https://slack.com/api/users.getPresence?token=" + url.QueryEscape(s.token) +
+		"&user=" + url.QueryEscape(userID))
+	if err != nil {
+		return false, fmt.Errorf("slackchat: error Get: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return false, fmt.Errorf("slackchat: error reading Body: %s", err)
+	}
+
+	var slackResp responseUsersGetPresence
+	if err := json.Unmarshal(body, &slackResp); err != nil {
+		return false, fmt.Errorf("slackchat: error parsing Slack resp: %s", err)
+	}
+
+	if !slackResp.Ok {
+		return false, fmt.Errorf("slackchat: error retrieving presence of %s: %s", userID, slackResp.Error)
+	}
+
+	return slackResp.Presence == "away", nil
+}
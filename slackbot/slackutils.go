@@ -3,32 +3,64 @@ package slackbot
 
 import (
 	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
+	"math/rand"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/i18n"
+	"github.com/antonmry/leanmanager/intent"
+	"github.com/antonmry/leanmanager/scheduler"
+)
 
-	"golang.org/x/net/websocket"
+var (
+	errNotReady        = errors.New("member not ready")
+	errAnswerTimeout   = errors.New("answer timeout")
+	errTurnInterrupted = errors.New("turn interrupted")
+	errInvalidReminder = errors.New("invalid reminder")
+	errNotTeamMember   = errors.New("not a team member")
+	errBusyInPrivate   = errors.New("already answering in private")
 )
 
 const (
-	timeout int = 120
+	timeout            int = 120
+	answerTimeout      int = 600
+	resumeReminderHour int = 17
 )
 
-// Message represents the message received from Slack
+// Message represents the message received from the chat, following the Slack RTM format
 type Message struct {
-	ID      uint64      `json:"id"`
-	Type    string      `json:"type"`
-	User    string      `json:"user,omitempty"`
-	Channel interface{} `json:"channel"`
-	Text    string      `json:"text"`
+	ID       uint64       `json:"id"`
+	Type     string       `json:"type"`
+	User     string       `json:"user,omitempty"`
+	Channel  interface{}  `json:"channel"`
+	Text     string       `json:"text"`
+	TS       string       `json:"ts,omitempty"`
+	ThreadTS string       `json:"thread_ts,omitempty"`
+	ReplyTo  uint64       `json:"reply_to,omitempty"`
+	Inviter  string       `json:"inviter,omitempty"`
+	Presence string       `json:"presence,omitempty"`
+	Users    []string     `json:"users,omitempty"`
+	Reaction string       `json:"reaction,omitempty"`
+	Item     *MessageItem `json:"item,omitempty"`
+	Direct   bool         `json:"-"`
+	Username string       `json:"-"`
+	Icon     string       `json:"-"`
+}
+
+// MessageItem is the message a reaction refers to
+type MessageItem struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
 }
 
 // Channel represents the Slack Channel or Group where the bot is participating
@@ -42,17 +74,6 @@ type Channel struct {
 // Member defines the participants in the Channel with the bot
 type Member string
 
-type responseRtmStart struct {
-	Ok    bool         `json:"ok"`
-	Error string       `json:"error"`
-	URL   string       `json:"url"`
-	Self  responseSelf `json:"self"`
-}
-
-type responseSelf struct {
-	ID string `json:"id"`
-}
-
 type atomicCounter struct {
 	sync.Mutex
 	i uint64
@@ -63,9 +84,58 @@ type dailyScheduler struct {
 	d map[string]api.DailyMeeting
 }
 
+// preferences are the settings read to write every message of a channel: its language and the persona of the bot
+type preferences struct {
+	language string
+	botName  string
+	botIcon  string
+}
+
+// preferencesController keeps the preferences apart from channelsDailyMap, so messages can be translated and sent
+// while the dailies are locked
+type preferencesController struct {
+	sync.RWMutex
+	p map[string]preferences
+}
+
 type pendingMsjController struct {
 	sync.Mutex
 	p map[string]map[string]chan Message
+
+	// delivering is held while a message is given to a conversation, so its channel isn't closed meanwhile
+	delivering sync.RWMutex
+}
+
+type pendingResumeController struct {
+	sync.Mutex
+	p map[string]map[string]bool
+}
+
+// runningDaily is the state of the Daily Meeting in progress, interrupt is closed to stop waiting the current member
+// and wake to stop waiting the end of a snooze
+type runningDaily struct {
+	facilitator  string
+	current      string
+	skipped      map[string]bool
+	ended        bool
+	stopped      bool
+	interrupt    chan struct{}
+	snoozedUntil time.Time
+	repeat       bool
+	wake         chan struct{}
+}
+
+const (
+	// defaultSnooze is how long `daily snooze` pauses the Daily Meeting if no time is given
+	defaultSnooze = 15 * time.Minute
+
+	// maxSnooze is the longest pause of a Daily Meeting
+	maxSnooze = 4 * time.Hour
+)
+
+type runningDailyController struct {
+	sync.Mutex
+	r map[string]*runningDaily
 }
 
 var counter = atomicCounter{}
@@ -78,61 +148,40 @@ var channelsDailyMap = dailyScheduler{
 	d: make(map[string]api.DailyMeeting),
 }
 
-// Connection methods
-
-func slackConnect(token string) (ws *websocket.Conn, botID string, err error) {
-	wsURL, botID, err := slackInit(token)
-	if err != nil {
-		return nil, "", fmt.Errorf("slackutils: error initiating communication with slack: %s", err)
-	}
+// dailyWrites serializes the writes of the Daily Meetings to the API Server, see persistDaily
+var dailyWrites sync.Mutex
 
-	ws, err = websocket.Dial(wsURL, "", "https://api.slack.com/")
-	if err != nil {
-		return nil, "", fmt.Errorf("slackutils: error creating websocket: %s", err)
-	}
-
-	return ws, botID, nil
+var channelsPrefs = preferencesController{
+	p: make(map[string]preferences),
 }
 
-func slackInit(token string) (wsurl, id string, err error) {
-	url := fmt.Sprintf("https://slack.com/api/rtm.start?token=%s", token)
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", "", fmt.Errorf("slackutils: error Get: %s", err)
-	}
-	if resp.StatusCode != 200 {
-		return "", "", fmt.Errorf("slackutils: error response from Get: %s", err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return "", "", fmt.Errorf("slackutils: error reading Body: %s", err)
-	}
+var pendingResumes = pendingResumeController{
+	p: make(map[string]map[string]bool),
+}
 
-	var slackResp responseRtmStart
-	err = json.Unmarshal(body, &slackResp)
-	if err != nil {
-		return "", "", fmt.Errorf("slackutils: error parsing Slack resp: %s", err)
-	}
+var runningDailies = runningDailyController{
+	r: make(map[string]*runningDaily),
+}
 
-	if !slackResp.Ok {
-		return "", "", fmt.Errorf("slackutils: error returning ko: %s", err)
-	}
+// set stores the Daily Meeting of the channel and its preferences, the dailies must be locked
+func (ds *dailyScheduler) set(channelID string, d api.DailyMeeting) {
+	ds.d[channelID] = d
 
-	wsurl = slackResp.URL
-	id = slackResp.Self.ID
-	return
+	channelsPrefs.Lock()
+	channelsPrefs.p[channelID] = preferences{language: d.Language, botName: d.BotName, botIcon: d.BotIcon}
+	channelsPrefs.Unlock()
 }
 
-func receiveMessage(ws *websocket.Conn) (m Message, err error) {
-	err = websocket.JSON.Receive(ws, &m)
-	return
+// get returns the preferences of the channel, the empty ones if it hasn't any
+func (pc *preferencesController) get(channelID string) preferences {
+	pc.RLock()
+	defer pc.RUnlock()
+	return pc.p[channelID]
 }
 
 // Messages management
 
-func manageHello(ws *websocket.Conn, m *Message) {
+func manageHello(chat ChatAdapter, m *Message) {
 
 	newChannel := api.Channel{
 		ID:     m.getChannelID(),
@@ -141,140 +190,403 @@ func manageHello(ws *websocket.Conn, m *Message) {
 
 	if err := storeChannel(&newChannel); err != nil {
 		log.Printf("slackutils: API Server is failing storing channel %s: %s\n", m.getChannelID(), err)
-		_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
 		return
 	}
 
-	if err := sendHelloMsj(ws, m.getChannelID()); err != nil {
+	if err := sendHelloMsj(chat, m.getChannelID()); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
-		_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
 	}
 	return
 }
 
-func manageHelp(ws *websocket.Conn, m *Message) {
+// manageBotJoined takes the admin from the member_joined_channel event of the bot, Slack doesn't send who invited it
+// in channel_joined. The event can arrive before channel_joined, so the channel is registered here too
+func manageBotJoined(chat ChatAdapter, m *Message) {
+	if m.Inviter == "" {
+		return
+	}
+
+	newChannel := api.Channel{
+		ID:     m.getChannelID(),
+		Name:   m.getChannelID(),
+		TeamID: teamID}
+
+	if err := storeChannel(&newChannel); err != nil {
+		log.Printf("slackutils: API Server is failing storing channel %s: %s\n", m.getChannelID(), err)
+		return
+	}
+	bootstrapAdmin(chat, m.getChannelID(), m.Inviter)
+}
+
+// bootstrapAdmin registers the user who invited the bot as the first admin of the channel and returns their ID,
+// nothing is done if the channel already has one. When the chat doesn't tell who invited the bot, userID is empty
+// and the creator of the channel is taken. Otherwise the admin has to be added with the master key of the API Server
+func bootstrapAdmin(chat ChatAdapter, channelID, userID string) string {
+	teamMembers, err := listTeamMembers(channelID)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
+		return ""
+	}
+
+	for _, tm := range teamMembers {
+		if tm.IsAdmin() {
+			return ""
+		}
+	}
 
-	if err := sendHelpMsj(ws, m.getChannelID()); err != nil {
+	if userID == "" {
+		userID, err = chat.ChannelCreator(channelID)
+	}
+	var user ChatUser
+	if err == nil && userID != "" {
+		if user, err = chat.GetUser(userID); err == nil && user.IsBot {
+			userID = ""
+		}
+	}
+	if err != nil || userID == "" {
+		log.Printf("slackutils: inviter and creator of channel %s unknown, it has no admin: %v", channelID, err)
+		if err := sendNoAdminMsj(chat, channelID); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+		}
+		return ""
+	}
+
+	admin, err := getTeamMember(channelID, userID)
+	if err != nil {
+		newMember := newTeamMember(user, channelID)
+		admin = &newMember
+	}
+	admin.Role = api.RoleAdmin
+
+	if err := addTeamMember(admin); err != nil {
+		log.Printf("slackutils: API Server is failing adding admin to channel %s: %v", channelID, err)
+		return ""
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: channelID,
+		Text: tr(channelID, "%s is the admin of the Daily Meeting :key: type `@leanmanager daily role @member admin` "+
+			"to add more admins", chat.Mention(userID)),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+	}
+	return userID
+}
+
+// checkAdmin returns true if the sender can change the configuration: they are admin, or the creator of a channel
+// without admins
+func checkAdmin(chat ChatAdapter, m *Message) bool {
+	teamMembers, err := listTeamMembers(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return false
+	}
+
+	var admins []string
+	for _, tm := range teamMembers {
+		if !tm.IsAdmin() {
+			continue
+		}
+		if tm.ID == m.User {
+			return true
+		}
+		admins = append(admins, chat.Mention(tm.ID))
+	}
+
+	// Nobody was told as inviter when the bot joined, the creator of the channel is the admin
+	if len(admins) == 0 {
+		adminID := bootstrapAdmin(chat, m.getChannelID(), "")
+		if adminID == "" || adminID == m.User {
+			return adminID != ""
+		}
+		admins = append(admins, chat.Mention(adminID))
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), ":no_entry: Only admins can do that, ask %s", strings.Join(admins, ", ")),
+	}
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
-		_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
 	}
-	return
+	return false
 }
 
-func manageAddMember(ws *websocket.Conn, m *Message) {
+// isAdmin checks if the user is an admin of the channel, without telling anything to the channel
+func isAdmin(channelID, userID string) bool {
+	member, err := getTeamMember(channelID, userID)
+	if err != nil {
+		return false
+	}
+	return member.IsAdmin()
+}
 
-	channelsMap.Lock()
-	if channelsMap.p[m.getChannelID()] == nil {
-		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+func manageHelp(chat ChatAdapter, m *Message) {
+
+	if err := sendHelpMsj(chat, m.getChannelID()); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
 	}
-	if channelsMap.p[m.getChannelID()]["<@"+m.User+">"] == nil {
-		channelsMap.p[m.getChannelID()]["<@"+m.User+">"] = make(chan Message)
-		defer channelsMap.finishWaitingMember(m.getChannelID(), "<@"+m.User+">")
+	return
+}
+
+func manageAddMember(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
 	}
-	channelsMap.Unlock()
 
 	message := &Message{
 		ID:      0,
 		Type:    "message",
 		User:    "",
 		Channel: m.getChannelID(),
-		Text:    "What members do you want to add to the Daily Meeting?",
 	}
-	if err := message.send(ws); err != nil {
-		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+
+	// The members can be typed after the command, otherwise they are asked
+	users := m.getCommandUserIDs(chat, "daily add member")
+	if len(users) == 0 {
+		var ok bool
+		if users, ok = askMembers(chat, m,
+			tr(m.getChannelID(), "What members do you want to add to the Daily Meeting?")); !ok {
+			return
+		}
 	}
 
-	var messageReceived Message
-	var users []string
+	channelMembers, err := getChannelMembers(chat, m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error retrieving members of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
 
-	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
-		if messageReceived.isCancel() {
-			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
-				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	for _, u := range users {
+		if !channelMembers[u] {
+			message.Text = tr(m.getChannelID(), ":no_entry: %s isn't a member of this channel, invite them first",
+				chat.Mention(u))
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending msj to channel %s: %s\n", m.getChannelID(), err)
 			}
-			return
+			continue
 		}
 
-		users = messageReceived.getValidUserIDs()
-		if len(users) > 0 {
-			break
+		user, err := chat.GetUser(u)
+		if err != nil {
+			log.Printf("slackutils: error retrieving user %s: %v", u, err)
+			user = ChatUser{ID: u, Name: u}
 		}
 
-		message.Text = ":scream: Type something like `@alice @bob and @carel` or `cancel`."
-		if err := message.send(ws); err != nil {
-			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		newMember := newTeamMember(user, m.getChannelID())
+		keepRole(&newMember)
+
+		if err := addTeamMember(&newMember); err != nil {
+			log.Printf("slackutils: API Server is failing adding member to channel %s: %v", m.getChannelID(), err)
+			_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+			return
+		}
+
+		message.Text = tr(m.getChannelID(), "Team member %s registered", chat.Mention(newMember.ID))
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending msj to channel %s: %s\n", m.getChannelID(), err)
 		}
+	}
+}
 
+func manageAddAllMembers(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
 	}
 
-	for _, u := range users {
-		newMember := api.Member{
-			ID:        u,
-			Name:      u,
-			ChannelID: m.getChannelID(),
-			TeamID:    teamID,
+	channelMembers, err := chat.ChannelMembers(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error retrieving members of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	var b bytes.Buffer
+	b.WriteString(tr(m.getChannelID(), "Team members registered: "))
+
+	var registered int
+	for _, u := range channelMembers {
+		user, err := chat.GetUser(u)
+		if err != nil {
+			log.Printf("slackutils: error retrieving user %s: %v", u, err)
+			continue
 		}
 
+		if user.IsBot {
+			continue
+		}
+
+		newMember := newTeamMember(user, m.getChannelID())
+		keepRole(&newMember)
+
 		if err := addTeamMember(&newMember); err != nil {
 			log.Printf("slackutils: API Server is failing adding member to channel %s: %v", m.getChannelID(), err)
-			_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
+			_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+			return
 		}
 
-		message.Text = "Team member " + newMember.Name + " registered"
-		if err := message.send(ws); err != nil {
+		b.WriteString(newMember.Name + ", ")
+		registered++
+	}
+
+	if registered == 0 {
+		if err := sendNotMembersRegisteredMsj(chat, m.getChannelID()); err != nil {
 			log.Printf("slackutils: error sending msj to channel %s: %s\n", m.getChannelID(), err)
 		}
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    b.String()[:len(b.String())-2],
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending msj to channel %s: %s\n", m.getChannelID(), err)
 	}
 }
 
-func manageDelMember(ws *websocket.Conn, m *Message) {
+func manageMemberLeft(chat ChatAdapter, m *Message) {
 
-	channelsMap.Lock()
-	if channelsMap.p[m.getChannelID()] == nil {
-		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+	memberToBeDeleted, err := getTeamMember(m.getChannelID(), m.User)
+	if err != nil {
+		return
 	}
-	if channelsMap.p[m.getChannelID()]["<@"+m.User+">"] == nil {
-		channelsMap.p[m.getChannelID()]["<@"+m.User+">"] = make(chan Message)
-		defer channelsMap.finishWaitingMember(m.getChannelID(), "<@"+m.User+">")
+
+	if err := delTeamMember(memberToBeDeleted); err != nil {
+		log.Printf("slackutils: API Server is failing deleting member in channel %s: %v", m.getChannelID(), err)
+		return
 	}
-	channelsMap.Unlock()
 
 	message := &Message{
 		ID:      0,
 		Type:    "message",
-		User:    "",
 		Channel: m.getChannelID(),
-		Text:    "Who isn't going to participate the Daily Meeting?",
+		Text: tr(m.getChannelID(), "Team member %s has left the channel, unregistered from the Daily Meeting",
+			memberToBeDeleted.Name),
 	}
-	if err := message.send(ws); err != nil {
-		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending msj to channel %s: %s\n", m.getChannelID(), err)
 	}
+}
 
-	var messageReceived Message
-	var users []string
+// getChannelMembers returns the set of IDs of the channel's members
+func getChannelMembers(chat ChatAdapter, channelID string) (map[string]bool, error) {
+	channelMembers, err := chat.ChannelMembers(channelID)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
-		if messageReceived.isCancel() {
-			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
-				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	members := make(map[string]bool, len(channelMembers))
+	for _, u := range channelMembers {
+		members[u] = true
+	}
+	return members, nil
+}
+
+func newTeamMember(user ChatUser, channelID string) api.Member {
+	return api.Member{
+		ID:        user.ID,
+		Name:      user.Name,
+		RealName:  user.RealName,
+		TimeZone:  user.TimeZone,
+		Avatar:    user.Avatar,
+		ChannelID: channelID,
+		TeamID:    teamID,
+	}
+}
+
+// keepRole preserves the role of members already registered
+func keepRole(member *api.Member) {
+	if registered, err := getTeamMember(member.ChannelID, member.ID); err == nil {
+		member.Role = registered.Role
+	}
+}
+
+func manageRoleDaily(chat ChatAdapter, m *Message) {
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager daily role @member admin`, `member` "+
+			"or `observer`"),
+	}
+
+	// Skip the bot's mention, only members after the command count
+	args := m.Text[strings.Index(m.Text, "daily role")+len("daily role"):]
+	users := chat.ParseMentions(args)
+	role := regexp.MustCompile("(?i)\\b(admin|member|observer)\\b").FindString(args)
+	if len(users) == 0 || role == "" {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	for _, u := range users {
+		member, err := getTeamMember(m.getChannelID(), u)
+		if err != nil {
+			user, err := chat.GetUser(u)
+			if err != nil {
+				log.Printf("slackutils: error retrieving user %s: %v", u, err)
+				user = ChatUser{ID: u, Name: u}
 			}
-			return
+			newMember := newTeamMember(user, m.getChannelID())
+			member = &newMember
 		}
+		member.Role = strings.ToLower(role)
 
-		users = messageReceived.getValidUserIDs()
-		if len(users) > 0 {
-			break
+		if err := addTeamMember(member); err != nil {
+			log.Printf("slackutils: API Server is failing adding member to channel %s: %v", m.getChannelID(), err)
+			_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+			return
 		}
 
-		message.Text = ":scream: Type something like `@alice @bob and @carel` or `cancel`."
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), "Done! %s is now %s", chat.Mention(u), tr(m.getChannelID(), member.Role))
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
+	}
+}
+
+func manageDelMember(chat ChatAdapter, m *Message) {
 
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		User:    "",
+		Channel: m.getChannelID(),
+	}
+
+	// The members can be typed after the command, otherwise they are asked
+	users := m.getCommandUserIDs(chat, "daily delete member")
+	if len(users) == 0 {
+		var ok bool
+		if users, ok = askMembers(chat, m,
+			tr(m.getChannelID(), "Who isn't going to participate the Daily Meeting?")); !ok {
+			return
+		}
 	}
 
 	for _, u := range users {
@@ -287,38 +599,87 @@ func manageDelMember(ws *websocket.Conn, m *Message) {
 
 		if err := delTeamMember(&memberToBeDeleted); err != nil {
 			log.Printf("slackutils: API Server is failing deleting member in channel %s: %v", m.getChannelID(), err)
-			_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
+			_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+			return
 		}
 
-		message.Text = "Team member " + memberToBeDeleted.Name + " unregistered"
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), "Team member %s unregistered", chat.Mention(memberToBeDeleted.ID))
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending msj to channel %s: %s\n", m.getChannelID(), err)
 		}
 	}
 }
 
-func manageListMembers(ws *websocket.Conn, m *Message) {
+// askMembers asks the question and waits for the members mentioned in the answer, ok is false if it's cancelled
+func askMembers(chat ChatAdapter, m *Message, question string) (users []string, ok bool) {
+	channelsMap.Lock()
+	if channelsMap.p[m.getChannelID()] == nil {
+		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+	}
+	if channelsMap.p[m.getChannelID()][m.User] == nil {
+		channelsMap.p[m.getChannelID()][m.User] = make(chan Message)
+		defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
+	}
+	channelsMap.Unlock()
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		User:    "",
+		Channel: m.getChannelID(),
+		Text:    question,
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+
+	for {
+		messageReceived := <-channelsMap.p[m.getChannelID()][m.User]
+		if messageReceived.isCancel() {
+			message.Text = ":ok_hand:"
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return nil, false
+		}
+
+		if users = messageReceived.getValidUserIDs(chat); len(users) > 0 {
+			return users, true
+		}
+
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `@alice @bob and @carel` or `cancel`.")
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+	}
+}
+
+func manageListMembers(chat ChatAdapter, m *Message) {
 
 	teamMembers, err := listTeamMembers(m.getChannelID())
 
 	if err != nil {
 		log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
-		_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
 		return
 	}
 
 	if len(teamMembers[:]) == 0 {
-		if err := sendNotMembersRegisteredMsj(ws, m.getChannelID()); err != nil {
+		if err := sendNotMembersRegisteredMsj(chat, m.getChannelID()); err != nil {
 			log.Printf("slackutils: error listing member in channel %s: %s\n", m.getChannelID(), err)
 		}
 		return
 	}
 
 	var b bytes.Buffer
-	b.WriteString("Members registered for the next Daily Sprint: ")
+	b.WriteString(tr(m.getChannelID(), "Members registered for the next Daily Sprint: "))
 
 	for i := 0; i < len(teamMembers[:]); i++ {
-		b.WriteString(teamMembers[i].ID + ", ")
+		b.WriteString(teamMembers[i].Name)
+		if teamMembers[i].IsAdmin() || teamMembers[i].IsObserver() {
+			b.WriteString(" (" + tr(m.getChannelID(), teamMembers[i].Role) + ")")
+		}
+		b.WriteString(", ")
 	}
 
 	message := &Message{
@@ -327,116 +688,449 @@ func manageListMembers(ws *websocket.Conn, m *Message) {
 		Channel: m.getChannelID(),
 		Text:    b.String()[:len(b.String())-2],
 	}
-	if err := message.send(ws); err != nil {
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error listing member in channel %s: %s\n", m.getChannelID(), err)
 	}
 	return
 
 }
 
-func manageStartDaily(ws *websocket.Conn, m *Message) {
+func manageStartDaily(chat ChatAdapter, m *Message) {
 
-	if err := sendStartDailyMsj(ws, m.getChannelID()); err != nil {
-		log.Printf("slackutils: error starting the daily in channel %s: %s\n", m.getChannelID(), err)
+	if !runningDailies.start(m.getChannelID()) {
+		message := &Message{
+			ID:      0,
+			Type:    "message",
+			Channel: m.getChannelID(),
+			Text:    tr(m.getChannelID(), ":warning: The Daily Meeting is already running"),
+		}
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
 	}
+	defer runningDailies.finish(m.getChannelID())
 
 	teamMembers, err := listTeamMembers(m.getChannelID())
 
 	if err != nil {
 		log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
-		_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
 		return
 	}
 
+	// Observers follow the meeting but they aren't asked
+	speakers := teamMembers[:0]
+	for _, tm := range teamMembers {
+		if !tm.IsObserver() {
+			speakers = append(speakers, tm)
+		}
+	}
+	teamMembers = speakers
+
 	if len(teamMembers[:]) == 0 {
-		if err := sendNotMembersRegisteredMsj(ws, m.getChannelID()); err != nil {
+		if err := sendNotMembersRegisteredMsj(chat, m.getChannelID()); err != nil {
 			log.Printf("slackutils: error listing member in channel %s: %s\n", m.getChannelID(), err)
 		}
 		return
 	}
 
+	// The order could have been changed through the API Server
+	order, err := getDailyOrder(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: using the last known order of channel %s: %v", m.getChannelID(), err)
+	}
+
+	// Who is away is asked to the chat before locking the dailies, only this meeting moves the facilitator rotation
 	channelsDailyMap.Lock()
-	d := channelsDailyMap.d[m.getChannelID()]
-	d.LastDaily = time.Now()
-	d.ChannelID = m.getChannelID()
-	channelsDailyMap.d[m.getChannelID()] = d
+	rotation := channelsDailyMap.d[m.getChannelID()].FacilitatorRotation
 	channelsDailyMap.Unlock()
-
-	if err := addDailyMeeting(&d, teamID); err != nil {
+	facilitator, rotation := pickFacilitator(chat, teamMembers, rotation)
+
+	// The rotations are moved with the dailies locked, other commands could change the rest of the settings
+	d, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.LastDaily = time.Now()
+		if order != nil {
+			d.Order = order.Order
+			d.ManualOrder = order.ManualOrder
+		}
+		teamMembers = sortTeamMembers(teamMembers, d)
+		d.Facilitator = facilitator
+		d.FacilitatorRotation = rotation
+	})
+	runningDailies.setFacilitator(m.getChannelID(), d.Facilitator)
+	if err != nil {
 		log.Printf("slackutils: error invoking API Server to store LastDaily time: %v", err)
-		_ = sendUnexpectedProblemMsj(ws, m.getChannelID())
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
 		return
 	}
 
-	var messageReceived Message
-
-	for i := 0; i < len(teamMembers[:]); i++ {
+	threadTS, err := sendStartDailyMsj(chat, m.getChannelID(), d.Threaded, d.Facilitator)
+	if err != nil {
+		log.Printf("slackutils: error starting the daily in channel %s: %s\n", m.getChannelID(), err)
+	}
+	remindActionItems(chat, m.getChannelID(), "", threadTS)
+
+	memberIDs := make([]string, len(teamMembers))
+	for i, tm := range teamMembers {
+		memberIDs[i] = tm.ID
+	}
+	startDailyDigest(m.getChannelID(), d.LastDaily, threadTS, memberIDs)
+	dailyProgresses.start(api.DailyProgress{
+		ChannelID:   m.getChannelID(),
+		Started:     d.LastDaily,
+		ThreadTS:    threadTS,
+		Facilitator: d.Facilitator,
+		Members:     memberIDs,
+	})
+
+	runDaily(chat, m.getChannelID(), teamMembers, threadTS)
+}
+
+// runDaily gives the turn to each member, but the ones who already had it before a restart, and ends the meeting
+func runDaily(chat ChatAdapter, channelID string, teamMembers []api.Member, threadTS string) {
+	for i := 0; i < len(teamMembers[:]); i++ {
+		if dailyProgresses.isDone(channelID, teamMembers[i].ID) {
+			continue
+		}
+
+		// Skipped members, or all of them once the facilitator ends the meeting, don't have turn
+		interrupt, ok := runningDailies.turn(channelID, teamMembers[i].ID)
+		if !ok {
+			continue
+		}
+
+		dailyProgresses.turn(channelID, teamMembers[i].ID)
+		runDailyTurn(chat, channelID, teamMembers[i], threadTS, interrupt)
+
+		// A snooze interrupts the turn, the member speaks again when the meeting is back
+		if waitDailySnooze(chat, channelID, threadTS) {
+			i--
+			continue
+		}
+		dailyProgresses.done(channelID, teamMembers[i].ID)
+	}
+	dailyProgresses.finish(channelID)
+
+	endDailyMeetingMessage := &Message{
+		ID:       0,
+		Type:     "message",
+		Channel:  channelID,
+		ThreadTS: threadTS,
+	}
+	if runningDailies.isStopped(channelID) {
+		endDailyMeetingMessage.Text = render(channelID, "daily-stopped", nil)
+	} else {
+		endDailyMeetingMessage.Text = render(channelID, "daily-done",
+			map[string]interface{}{"Content": getDailyContent(channelID)})
+	}
+	if err := endDailyMeetingMessage.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+	}
+
+	if !runningDailies.isStopped(channelID) {
+		postWeeklyMoodReport(chat, channelID)
+	}
+}
+
+// runDailyTurn asks the member if they are ready and then the questions of the Daily Meeting. A member who was
+// answering before a restart goes on with the next question
+func runDailyTurn(chat ChatAdapter, channelID string, member api.Member, threadTS string, interrupt <-chan struct{}) {
+	if dailyProgresses.answered(channelID, member.ID) == 0 {
+		message := &Message{
+			ID:       0,
+			Type:     "message",
+			Channel:  channelID,
+			Text:     render(channelID, "daily-ready", map[string]interface{}{"Member": chat.Mention(member.ID)}),
+			ThreadTS: threadTS,
+		}
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+		}
+
+		err := waitMemberReady(chat, channelID, member.ID, threadTS, interrupt)
+		if err == errTurnInterrupted {
+			return
+		}
+		if err != nil {
+			if err := sendNotAvailableMsj(chat, channelID, threadTS); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+			}
+			markPendingResume(chat, channelID, member)
+			return
+		}
+	}
+
+	pendingResumes.finish(channelID, member.ID)
+	remindActionItems(chat, channelID, member.ID, threadTS)
+	from := dailyProgresses.answered(channelID, member.ID)
+	_, err := runDailyByMember(chat, channelID, channelID, member.ID, threadTS, from, interrupt)
+	if err == errAnswerTimeout {
+		markPendingResume(chat, channelID, member)
+	}
+}
+
+// waitDailySnooze ends the turn and waits while the meeting is snoozed, returning true if the turn has to be
+// repeated
+func waitDailySnooze(chat ChatAdapter, channelID, threadTS string) bool {
+	repeat, snoozed := runningDailies.waitSnooze(channelID)
+	if !snoozed || runningDailies.isEnded(channelID) {
+		return repeat
+	}
+
+	message := &Message{
+		ID:       0,
+		Type:     "message",
+		Channel:  channelID,
+		Text:     render(channelID, "daily-back", nil),
+		ThreadTS: threadTS,
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+	}
+	return repeat
+}
+
+// pickFacilitator chooses the next member in round-robin who isn't away and returns the rotation moved forward, or
+// the same one if everybody is away
+func pickFacilitator(chat ChatAdapter, teamMembers []api.Member, rotation int) (string, int) {
+	candidates := make([]api.Member, len(teamMembers))
+	copy(candidates, teamMembers)
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	for i := 0; i < len(candidates); i++ {
+		next := (rotation + i) % len(candidates)
+		away, err := chat.IsAway(candidates[next].ID)
+		if err != nil {
+			log.Printf("slackutils: error checking if %s is away: %v", candidates[next].ID, err)
+		}
+		if away {
+			continue
+		}
+		return candidates[next].ID, next + 1
+	}
+	return "", rotation
+}
+
+func manageSkipDaily(chat ChatAdapter, m *Message) {
+	if !checkFacilitator(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), ":scream: Type something like `@leanmanager daily skip @member`"),
+	}
+
+	// Skip the bot's mention, only members after the command count
+	users := chat.ParseMentions(m.Text[strings.Index(m.Text, "daily skip"):])
+	if len(users) == 0 {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	for _, u := range users {
+		runningDailies.skip(m.getChannelID(), u)
+		dailyProgresses.skip(m.getChannelID(), u)
+		message.Text = tr(m.getChannelID(), "%s skipped by %s :fast_forward:", chat.Mention(u), chat.Mention(m.User))
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+	}
+}
+
+func manageSnoozeDaily(chat ChatAdapter, m *Message) {
+	if !checkFacilitator(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), ":scream: Type something like `@leanmanager daily snooze 15m`, up to %s", maxSnooze),
+	}
+
+	snooze, err := m.getValidSnooze()
+	if err != nil {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	until := time.Now().Add(snooze)
+	runningDailies.snooze(m.getChannelID(), until)
+	dailyProgresses.snooze(m.getChannelID(), until)
+
+	message.Text = tr(m.getChannelID(), "Daily Meeting snoozed :sleeping: I'll go on at %s", until.Format("15:04"))
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageStopDaily(chat ChatAdapter, m *Message) {
+	if !checkFacilitator(chat, m) {
+		return
+	}
+
+	runningDailies.stop(m.getChannelID())
+}
+
+func manageEndDaily(chat ChatAdapter, m *Message) {
+	if !checkFacilitator(chat, m) {
+		return
+	}
+
+	runningDailies.end(m.getChannelID())
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), "The facilitator has ended the Daily Meeting :checkered_flag:"),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// checkFacilitator returns true if there is a Daily Meeting running and the message was sent by its facilitator
+// or an admin
+func checkFacilitator(chat ChatAdapter, m *Message) bool {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), "There isn't any Daily Meeting running now, type `@leanmanager daily start` to "+
+			"start it"),
+	}
+
+	facilitator, running := runningDailies.getFacilitator(m.getChannelID())
+	switch {
+	case !running:
+	case facilitator != "" && facilitator != m.User && !isAdmin(m.getChannelID(), m.User):
+		message.Text = tr(m.getChannelID(), ":no_entry: Only today's facilitator %s or an admin can do that",
+			chat.Mention(facilitator))
+	default:
+		return true
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+	return false
+}
 
-		message := &Message{
-			ID:      0,
-			Type:    "message",
-			Channel: m.getChannelID(),
-			Text:    "Hi " + teamMembers[i].ID + "! Are you ready?.",
-		}
-		if err := message.send(ws); err != nil {
-			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
-		}
+// manageResumeDaily asks the member the questions of today's Daily Meeting they didn't answer. In a direct message,
+// it resumes the reports they owe in all the channels
+func manageResumeDaily(chat ChatAdapter, m *Message) {
+	if m.User == "" {
+		return
+	}
 
-		channelsMap.Lock()
-		if channelsMap.p[m.getChannelID()] == nil {
-			channelsMap.p[m.getChannelID()] = map[string]chan Message{}
-		}
-		if channelsMap.p[m.getChannelID()][teamMembers[i].ID] == nil {
-			channelsMap.p[m.getChannelID()][teamMembers[i].ID] = make(chan Message)
-			defer channelsMap.finishWaitingMember(m.getChannelID(), teamMembers[i].ID)
-		}
-		channelsMap.Unlock()
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+	}
 
-		var memberAvailable bool
-		for {
-			select {
-			case <-time.After(time.Second * time.Duration(timeout)):
-				memberAvailable = false
-			case messageReceived = <-channelsMap.p[m.getChannelID()][teamMembers[i].ID]:
-				memberAvailable = true
-			}
-			if !memberAvailable || (messageReceived.isYes() || messageReceived.isNo()) {
-				break
-			}
+	if m.Direct {
+		digests, err := listOwedDigests(m.User)
+		if err != nil {
+			log.Printf("slackutils: error invoking API Server to retrieve daily digests: %v", err)
+			_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+			return
 		}
-
-		if messageReceived.isNo() || !memberAvailable {
-			if err := sendNotAvailableMsj(ws, m.getChannelID()); err != nil {
+		if len(digests) == 0 {
+			message.Text = tr(m.getChannelID(), "You don't owe any Daily report today :+1:")
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
-			continue
+			return
+		}
+		for _, digest := range digests {
+			resumeDailyReport(chat, digest, m.getChannelID(), m.User)
 		}
+		return
+	}
 
-		runDailyByMember(ws, m.getChannelID(), teamMembers[i].ID)
+	digest, err := getDailyDigest(m.getChannelID(), time.Now())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve daily digest: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
 	}
 
-	endDailyMeetingMessage := &Message{
-		ID:      0,
-		Type:    "message",
-		Channel: m.getChannelID(),
-		Text:    "Daily Meeting done :tada: Have a great day!",
+	switch {
+	case digest == nil:
+		message.Text = tr(m.getChannelID(), "There hasn't been any Daily Meeting today, type `@leanmanager daily start` "+
+			"to start it")
+	case !digest.HasMember(m.User):
+		message.Text = tr(m.getChannelID(), "You weren't in today's Daily Meeting, there is nothing to resume "+
+			":thinking_face:")
+	case digest.Answered(m.User) >= len(api.DefaultQuestions[api.MeetingDaily]):
+		message.Text = tr(m.getChannelID(), "You already gave your Daily report today :+1:")
+	default:
+		resumeDailyReport(chat, *digest, m.getChannelID(), m.User)
+		return
 	}
-	if err := endDailyMeetingMessage.send(ws); err != nil {
+
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
-
-	return
 }
 
-func manageResumeDaily(ws *websocket.Conn, m *Message) {
-	if m.User != "" {
-		runDailyByMember(ws, m.getChannelID(), "<@"+m.User+">")
-		return
+// sortTeamMembers returns the members in the speaking order of the Daily Meeting, moving the rotation forward
+func sortTeamMembers(teamMembers []api.Member, d *api.DailyMeeting) []api.Member {
+	sorted := make([]api.Member, len(teamMembers))
+	copy(sorted, teamMembers)
+
+	switch d.Order {
+	case api.OrderRandom:
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		r.Shuffle(len(sorted), func(i, j int) {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		})
+	case api.OrderAlphabetical:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(sorted[i].Name) < strings.ToLower(sorted[j].Name)
+		})
+	case api.OrderRotation:
+		if len(sorted) == 0 {
+			break
+		}
+		offset := d.Rotation % len(sorted)
+		sorted = append(sorted[offset:], sorted[:offset]...)
+		d.Rotation = offset + 1
+	case api.OrderManual:
+		position := make(map[string]int, len(d.ManualOrder))
+		for i, id := range d.ManualOrder {
+			position[id] = i
+		}
+		// Members not included in the manual order speak at the end
+		sort.SliceStable(sorted, func(i, j int) bool {
+			pi, iok := position[sorted[i].ID]
+			pj, jok := position[sorted[j].ID]
+			if iok && jok {
+				return pi < pj
+			}
+			return iok && !jok
+		})
 	}
+
+	return sorted
 }
 
-func runDailyByMember(ws *websocket.Conn, channelID, memberID string) {
-	// Initialization to wait for user responses
+// waitMemberReady returns nil when the member answers yes, errNotReady if no, errAnswerTimeout if the ready
+// timeout expires or errTurnInterrupted if the facilitator skips the member
+func waitMemberReady(chat ChatAdapter, channelID, memberID, threadTS string, interrupt <-chan struct{}) error {
 	channelsMap.Lock()
 	if channelsMap.p[channelID] == nil {
 		channelsMap.p[channelID] = map[string]chan Message{}
@@ -447,86 +1141,198 @@ func runDailyByMember(ws *websocket.Conn, channelID, memberID string) {
 	}
 	channelsMap.Unlock()
 
-	// Start the daily meeting
-	dailyMeetingMessage := &Message{
+	readyTimeout, _ := getDailyTimeouts(channelID)
+	for {
+		m, err := channelsMap.receiveInThread(chat, channelID, channelID, memberID, threadTS, readyTimeout,
+			interrupt)
+		switch {
+		case err != nil:
+			return err
+		case m.isNo():
+			return errNotReady
+		case m.isYes():
+			return nil
+		}
+	}
+}
+
+// getDailyTimeouts returns how long to wait for members to be ready and for each answer
+func getDailyTimeouts(channelID string) (ready, answer time.Duration) {
+	channelsDailyMap.Lock()
+	d := channelsDailyMap.d[channelID]
+	channelsDailyMap.Unlock()
+
+	ready = time.Second * time.Duration(timeout)
+	if d.ReadyTimeout > 0 {
+		ready = time.Second * time.Duration(d.ReadyTimeout)
+	}
+	answer = time.Second * time.Duration(answerTimeout)
+	if d.AnswerTimeout > 0 {
+		answer = time.Second * time.Duration(d.AnswerTimeout)
+	}
+	return ready, answer
+}
+
+// markPendingResume keeps the member as pending of the Daily report until they resume it
+func markPendingResume(chat ChatAdapter, channelID string, member api.Member) {
+	pendingResumes.add(channelID, member.ID)
+	go remindResumeDaily(chat, channelID, member)
+}
+
+// remindResumeDaily nudges the member who missed the Daily Meeting at the end of their day, in their timezone
+func remindResumeDaily(chat ChatAdapter, channelID string, member api.Member) {
+	now := time.Now().In(member.Location())
+	reminder := time.Date(now.Year(), now.Month(), now.Day(), resumeReminderHour, 0, 0, 0, now.Location())
+	if !reminder.After(now) {
+		return
+	}
+
+	time.Sleep(reminder.Sub(now))
+	if !pendingResumes.isPending(channelID, member.ID) {
+		return
+	}
+
+	message := &Message{
 		ID:      0,
 		Type:    "message",
 		Channel: channelID,
-		Text:    memberID + ", what did you do yesterday?",
+		Text:    render(channelID, "daily-resume-reminder", map[string]interface{}{"Member": chat.Mention(member.ID)}),
 	}
-	if err := dailyMeetingMessage.send(ws); err != nil {
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
-		return
 	}
+}
 
-	m := <-channelsMap.p[channelID][memberID]
+// runDailyByMember asks the Daily questions to the member from the one given, returning the answers and
+// errAnswerTimeout if any answer times out or errTurnInterrupted if the facilitator skips the member. The settings
+// are the ones of channelID, the channel of the meeting, and the member is asked in askChannelID, the same channel
+// or a direct one
+func runDailyByMember(chat ChatAdapter, channelID, askChannelID, memberID, threadTS string, from int,
+	interrupt <-chan struct{}) ([]api.DailyAnswer, error) {
+	// Initialization to wait for user responses
+	channelsMap.Lock()
+	if channelsMap.p[askChannelID] == nil {
+		channelsMap.p[askChannelID] = map[string]chan Message{}
+	}
+	if channelsMap.p[askChannelID][memberID] == nil {
+		channelsMap.p[askChannelID][memberID] = make(chan Message)
+		defer channelsMap.finishWaitingMember(askChannelID, memberID)
+	}
+	channelsMap.Unlock()
 
-	if r := m.getPredefinedReply(0); r != "" {
-		dailyMeetingMessage.Text = r
-		if err := dailyMeetingMessage.send(ws); err != nil {
-			log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
-			return
+	// The mood is asked before the first question, not again when the member goes on later
+	if from == 0 {
+		if err := askMood(chat, channelID, askChannelID, memberID, threadTS, interrupt); err != nil {
+			return nil, err
 		}
 	}
 
-	dailyMeetingMessage.Text = memberID + ", what will you do today?"
-	if err := dailyMeetingMessage.send(ws); err != nil {
-		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
-		return
-	}
+	return askQuestions(chat, channelID, askChannelID, memberID, threadTS, api.DefaultQuestions[api.MeetingDaily],
+		from, true, interrupt)
+}
 
-	m = <-channelsMap.p[channelID][memberID]
+// askQuestions asks the member in askChannelID each question from the one given and returns the answers, with the
+// predefined replies of the Daily Meeting if replies is set. The settings are the ones of channelID
+func askQuestions(chat ChatAdapter, channelID, askChannelID, memberID, threadTS string, questions []string, from int,
+	replies bool, interrupt <-chan struct{}) (answers []api.DailyAnswer, err error) {
+
+	_, limit := getDailyTimeouts(channelID)
+
+	meetingMessage := &Message{
+		ID:       0,
+		Type:     "message",
+		Channel:  askChannelID,
+		ThreadTS: threadTS,
+	}
+	meetingMessage.Username, meetingMessage.Icon = getPersona(channelID)
+
+	for q := from; q < len(questions); q++ {
+		meetingMessage.Text = render(channelID, "daily-question", map[string]interface{}{
+			"Member":   chat.Mention(memberID),
+			"Question": tr(channelID, questions[q]),
+		})
+		if err := meetingMessage.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", askChannelID, err)
+			return answers, nil
+		}
 
-	if r := m.getPredefinedReply(1); r != "" {
-		dailyMeetingMessage.Text = r
-		if err := dailyMeetingMessage.send(ws); err != nil {
-			log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
-			return
+		m, err := channelsMap.receiveInThread(chat, channelID, askChannelID, memberID, threadTS, limit, interrupt)
+		if err != nil {
+			return answers, stopRunDailyByMember(chat, channelID, askChannelID, memberID, threadTS, err)
+		}
+		answers = append(answers, api.DailyAnswer{MemberID: memberID, Question: q, Text: m.Text, Date: time.Now()})
+		dailyProgresses.answer(channelID, memberID, q, m.Text)
+
+		if !replies {
+			continue
+		}
+		if r := m.getPredefinedReply(q); r != "" {
+			meetingMessage.Text = r
+			if err := meetingMessage.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", askChannelID, err)
+				return answers, nil
+			}
 		}
 	}
-	dailyMeetingMessage.Text = memberID + ", are there any impediments in your way?"
-	if err := dailyMeetingMessage.send(ws); err != nil {
-		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
-		return
+
+	meetingMessage.Text = render(channelID, "daily-thanks", map[string]interface{}{"Member": chat.Mention(memberID)})
+	if err := meetingMessage.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", askChannelID, err)
 	}
+	return answers, nil
+}
 
-	m = <-channelsMap.p[channelID][memberID]
-	if r := m.getPredefinedReply(2); r != "" {
-		dailyMeetingMessage.Text = r
-		if err := dailyMeetingMessage.send(ws); err != nil {
-			log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
-			return
+// stopRunDailyByMember informs where the member was asked if they didn't answer in time and returns the cause
+func stopRunDailyByMember(chat ChatAdapter, channelID, askChannelID, memberID, threadTS string, cause error) error {
+	if cause == errAnswerTimeout {
+		if err := sendAnswerTimeoutMsj(chat, channelID, askChannelID, memberID, threadTS); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", askChannelID, err)
 		}
 	}
-	dailyMeetingMessage.Text = "Thanks " + memberID
-	if err := dailyMeetingMessage.send(ws); err != nil {
-		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
-		return
-	}
+	return cause
 }
 
-func manageUnderstoodCommand(ws *websocket.Conn, m *Message) {
+func manageUnderstoodCommand(chat ChatAdapter, m *Message, botMention string) {
 	message := &Message{
 		ID:      0,
 		Type:    "message",
 		User:    "",
 		Channel: m.getChannelID(),
-		Text:    ":interrobang:",
+		Text:    tr(m.getChannelID(), ":interrobang: Type `@leanmanager help` to know what I understand"),
+	}
+
+	text, _ := m.getCommandText(botMention)
+	if suggestions := intent.Suggest(text); len(suggestions) > 0 {
+		for i, s := range suggestions {
+			suggestions[i] = "`@leanmanager " + s + "`"
+		}
+		message.Text = tr(m.getChannelID(), ":interrobang: Did you mean %s?", strings.Join(suggestions, ", "))
 	}
-	if err := message.send(ws); err != nil {
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 }
 
-func manageScheduleDaily(ws *websocket.Conn, m *Message) {
+func manageScheduleDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	// A cron expression or a recurrence rule can be typed after the command
+	expr := m.Text[strings.Index(m.Text, "daily schedule")+len("daily schedule"):]
+	if expr = strings.Trim(strings.TrimSpace(expr), "`"); expr != "" {
+		manageScheduleExpression(chat, m, expr)
+		return
+	}
 
 	channelsMap.Lock()
 	if channelsMap.p[m.getChannelID()] == nil {
 		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
 	}
-	if channelsMap.p[m.getChannelID()]["<@"+m.User+">"] == nil {
-		channelsMap.p[m.getChannelID()]["<@"+m.User+">"] = make(chan Message)
-		defer channelsMap.finishWaitingMember(m.getChannelID(), "<@"+m.User+">")
+	if channelsMap.p[m.getChannelID()][m.User] == nil {
+		channelsMap.p[m.getChannelID()][m.User] = make(chan Message)
+		defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
 	}
 	channelsMap.Unlock()
 
@@ -535,9 +1341,9 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 		Type:    "message",
 		User:    "",
 		Channel: m.getChannelID(),
-		Text:    "What days of the week you would like to run the Daily meeting?",
+		Text:    tr(m.getChannelID(), "What days of the week you would like to run the Daily meeting?"),
 	}
-	if err := message.send(ws); err != nil {
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 
@@ -545,10 +1351,10 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 	var doW []time.Weekday
 
 	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 		if messageReceived.isCancel() {
 			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
 			return
@@ -559,15 +1365,16 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 			break
 		}
 
-		message.Text = ":scream: Type something like `weekdays`, `monday tuesday wednesday` or `cancel`."
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `weekdays`, `monday tuesday wednesday` or "+
+			"`cancel`.")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 
 	}
 
-	message.Text = "What time do you want to start the meeting? :clock2:"
-	if err := message.send(ws); err != nil {
+	message.Text = tr(m.getChannelID(), "What time do you want to start the meeting? :clock2:")
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 
@@ -575,11 +1382,11 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 	var startTime time.Time
 
 	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 
 		if messageReceived.isCancel() {
 			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
 			return
@@ -593,34 +1400,34 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 			break
 		}
 
-		message.Text = ":scream: Type something like `13:00`, `08:00AM` or `cancel`."
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `13:00`, `08:00AM` or `cancel`.")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 	}
 
-	message.Text = "Do you want stablish a flexible time based in your team's members activity?"
-	if err := message.send(ws); err != nil {
+	message.Text = tr(m.getChannelID(), "Do you want stablish a flexible time based in your team's members activity?")
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 
 	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 
 		if messageReceived.isCancel() {
 			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
 			return
 		}
 
 		if messageReceived.isNo() {
-			if err := storeScheduledTime(m.getChannelID(), time.Time{}, startTime, time.Time{}, doW); err != nil {
-				sendUnexpectedProblemMsj(ws, m.getChannelID())
+			if err := storeScheduledTime(m.getChannelID(), "", startTime, time.Time{}, 0, doW); err != nil {
+				sendUnexpectedProblemMsj(chat, m.getChannelID())
 				return
 			}
-			manageInfoDaily(ws, m)
+			manageInfoDaily(chat, m)
 			return
 		}
 
@@ -628,14 +1435,14 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 			break
 		}
 
-		message.Text = ":scream: Type something like `yes`, `no` or `cancel`."
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `yes`, `no` or `cancel`.")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 	}
 
-	message.Text = "What time is the limit to start? :clock8:"
-	if err := message.send(ws); err != nil {
+	message.Text = tr(m.getChannelID(), "What time is the limit to start? :clock8:")
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 
@@ -643,11 +1450,11 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 	var limitTime time.Time
 
 	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 
 		if messageReceived.isCancel() {
 			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
 			return
@@ -662,87 +1469,404 @@ func manageScheduleDaily(ws *websocket.Conn, m *Message) {
 		}
 
 		if !limitTime.Before(startTime) {
-			message.Text = ":scream: Type something like `13:00`, `08:00AM` or `cancel`."
+			message.Text = tr(m.getChannelID(), ":scream: Type something like `13:00`, `08:00AM` or `cancel`.")
 		} else {
-			message.Text = "Ok, it's not how you start, it's how you finish.. but you have to start first :stuck_out_tongue_closed_eyes:"
+			message.Text = tr(m.getChannelID(), "Ok, it's not how you start, it's how you finish.. but you have to "+
+				"start first :stuck_out_tongue_closed_eyes:")
+		}
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+	}
+
+	message.Text = tr(m.getChannelID(), "Which percentage of the team must be online to start? (%d%% by default) "+
+		":busts_in_silhouette:", defaultActiveShare)
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+
+	var share int
+
+	for {
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
+
+		if messageReceived.isCancel() {
+			message.Text = ":ok_hand:"
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return
+		}
+
+		var err error
+		if share, err = messageReceived.getValidPercentage(); err == nil {
+			break
+		}
+
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `50%`, `100` or `cancel`.")
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+	}
+
+	if err := storeScheduledTime(m.getChannelID(), "", startTime, limitTime, share, doW); err != nil {
+		sendUnexpectedProblemMsj(chat, m.getChannelID())
+	}
+
+	manageInfoDaily(chat, m)
+}
+
+func manageScheduleExpression(chat ChatAdapter, m *Message, expr string) {
+
+	// Intervals of recurrence rules count from today, days and hour like `weekdays 9:30` are a cron expression
+	expr, err := scheduler.Every(expr, time.Now())
+	if err != nil {
+		message := &Message{
+			ID:      0,
+			Type:    "message",
+			Channel: m.getChannelID(),
+			Text: ":scream: " + strings.TrimPrefix(err.Error(), "scheduler: ") + ". " + tr(m.getChannelID(),
+				"Type something like `@leanmanager daily schedule weekdays 9:30`, "+
+					"`@leanmanager daily schedule 0 9 * * 1-5` or "+
+					"`@leanmanager daily schedule FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;BYHOUR=9`"),
+		}
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	if err := storeScheduledTime(m.getChannelID(), expr, time.Time{}, time.Time{}, 0, nil); err != nil {
+		sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	manageInfoDaily(chat, m)
+}
+
+// storeScheduledTime replaces the schedule of the Daily Meeting: an expression or the days and hours
+func storeScheduledTime(channelID, schedule string, startTime, limitTime time.Time, activeShare int,
+	doW []time.Weekday) error {
+
+	_, err := updateDaily(channelID, func(d *api.DailyMeeting) {
+		d.StartTime = startTime
+		d.LimitTime = limitTime
+		d.ActiveShare = activeShare
+		d.Days = doW
+		d.Schedule = schedule
+	})
+	return err
+}
+
+// updateDaily changes the Daily Meeting of the channel with channelsDailyMap locked, so commands running at the same
+// time don't overwrite the settings changed by the others, and then stores it in the API Server. The update must not
+// call the chat or the API Server, every channel waits for it
+func updateDaily(channelID string, update func(d *api.DailyMeeting)) (api.DailyMeeting, error) {
+	channelsDailyMap.Lock()
+	d := channelsDailyMap.d[channelID]
+	d.ChannelID = channelID
+	update(&d)
+	channelsDailyMap.set(channelID, d)
+	channelsDailyMap.Unlock()
+
+	return d, persistDaily(channelID)
+}
+
+// persistDaily stores the Daily Meeting of the channel as it is now in channelsDailyMap, without keeping it locked
+// while the API Server answers. The writes go one by one and each one sends the last settings, so a slow write
+// never overwrites a newer one
+func persistDaily(channelID string) error {
+	dailyWrites.Lock()
+	defer dailyWrites.Unlock()
+
+	channelsDailyMap.Lock()
+	d := channelsDailyMap.d[channelID]
+	channelsDailyMap.Unlock()
+
+	return addDailyMeeting(&d, teamID)
+}
+
+func manageThreadedDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	channelsMap.Lock()
+	if channelsMap.p[m.getChannelID()] == nil {
+		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+	}
+	if channelsMap.p[m.getChannelID()][m.User] == nil {
+		channelsMap.p[m.getChannelID()][m.User] = make(chan Message)
+		defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
+	}
+	channelsMap.Unlock()
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		User:    "",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), "Do you want to run the Daily Meeting inside a single thread? :thread:"),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+
+	var messageReceived Message
+
+	for {
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
+		if messageReceived.isCancel() {
+			message.Text = ":ok_hand:"
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return
+		}
+
+		if messageReceived.isYes() || messageReceived.isNo() {
+			break
 		}
-		if err := message.send(ws); err != nil {
+
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `yes`, `no` or `cancel`.")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 	}
 
-	if err := storeScheduledTime(m.getChannelID(), time.Time{}, startTime, limitTime, doW); err != nil {
-		sendUnexpectedProblemMsj(ws, m.getChannelID())
+	d, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.Threaded = messageReceived.isYes()
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	if d.Threaded {
+		message.Text = tr(m.getChannelID(), "Done! Next Daily Meetings will run inside a thread :thread:")
+	} else {
+		message.Text = tr(m.getChannelID(), "Done! Next Daily Meetings will run in the channel :+1:")
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageTimeoutDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	channelsMap.Lock()
+	if channelsMap.p[m.getChannelID()] == nil {
+		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
+	}
+	if channelsMap.p[m.getChannelID()][m.User] == nil {
+		channelsMap.p[m.getChannelID()][m.User] = make(chan Message)
+		defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
+	}
+	channelsMap.Unlock()
+
+	readyTimeout, answerTimeout := getDailyTimeouts(m.getChannelID())
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		User:    "",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), "How many minutes should I wait for members to be ready? Now it's %d :hourglass:",
+			int(readyTimeout.Minutes())),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+
+	var minutes [2]int
+	for i := 0; i < len(minutes); i++ {
+		for {
+			messageReceived := <-channelsMap.p[m.getChannelID()][m.User]
+			if messageReceived.isCancel() {
+				message.Text = ":ok_hand:"
+				if err := message.send(chat); err != nil {
+					log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+				}
+				return
+			}
+
+			var err error
+			if minutes[i], err = messageReceived.getValidMinutes(); err == nil {
+				break
+			}
+
+			message.Text = tr(m.getChannelID(), ":scream: Type something like `5`, `10m`, `1h` or `cancel`.")
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+		}
+
+		if i == 0 {
+			message.Text = tr(m.getChannelID(), "And how many minutes for each answer? Now it's %d",
+				int(answerTimeout.Minutes()))
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+		}
+	}
+
+	_, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.ReadyTimeout = minutes[0] * 60
+		d.AnswerTimeout = minutes[1] * 60
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
 	}
 
-	manageInfoDaily(ws, m)
+	message.Text = tr(m.getChannelID(), "Done! Members will have %d minutes to be ready and %d minutes for each answer, "+
+		"I'll nudge them in private halfway through :bell:", minutes[0], minutes[1])
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
 }
 
-func storeScheduledTime(channelID string, lastDaily, startTime, limitTime time.Time, doW []time.Weekday) error {
+func manageOrderDaily(chat ChatAdapter, m *Message) {
+	if !checkAdmin(chat, m) {
+		return
+	}
 
-	channelsDailyMap.Lock()
-	defer channelsDailyMap.Unlock()
-	dailyToAdd := api.DailyMeeting{
-		ChannelID: channelID,
-		LastDaily: time.Time{},
-		StartTime: startTime,
-		LimitTime: limitTime,
-		Days:      doW,
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		User:    "",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager daily order random`, `rotation`, "+
+			"`alphabetical` or `manual @member1 @member2`"),
+	}
+
+	order := m.getValidOrder()
+	var manualOrder []string
+	if order == api.OrderManual {
+		// Skip the bot's mention, only members after the strategy count
+		manualOrder = chat.ParseMentions(m.Text[strings.Index(strings.ToLower(m.Text), api.OrderManual):])
+	}
+
+	if order == "" || (order == api.OrderManual && len(manualOrder) == 0) {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
 	}
 
-	channelsDailyMap.d[channelID] = dailyToAdd
+	_, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.Order = order
+		d.ManualOrder = manualOrder
+		d.Rotation = 0
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
 
-	return addDailyMeeting(&dailyToAdd, teamID)
+	switch order {
+	case api.OrderRandom:
+		message.Text = tr(m.getChannelID(), "Done! Members will speak in random order :game_die:")
+	case api.OrderRotation:
+		message.Text = tr(m.getChannelID(), "Done! A different member will start each Daily Meeting "+
+			":arrows_counterclockwise:")
+	case api.OrderAlphabetical:
+		message.Text = tr(m.getChannelID(), "Done! Members will speak in alphabetical order :abc:")
+	case api.OrderManual:
+		mentions := make([]string, len(manualOrder))
+		for i, id := range manualOrder {
+			mentions[i] = chat.Mention(id)
+		}
+		message.Text = tr(m.getChannelID(), "Done! Members will speak in this order: %s", strings.Join(mentions, ", "))
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
 }
 
-func manageInfoDaily(ws *websocket.Conn, m *Message) {
+func manageInfoDaily(chat ChatAdapter, m *Message) {
 	message := &Message{
 		ID:      0,
 		Type:    "message",
 		User:    "",
 		Channel: m.getChannelID(),
-		Text: "There is no Daily Meeting scheduled yet, type `@leanmanager daily schedule` " +
-			"to schedule your next Daily Meeting",
+		Text: tr(m.getChannelID(), "There is no Daily Meeting scheduled yet, type `@leanmanager daily schedule` "+
+			"to schedule your next Daily Meeting"),
 	}
-	channelsDailyMap.Lock()
 
-	if i, ok := channelsDailyMap.d[m.getChannelID()]; ok {
+	// The settings are copied, so the dailies aren't locked while the message is written
+	channelsDailyMap.Lock()
+	i, ok := channelsDailyMap.d[m.getChannelID()]
+	channelsDailyMap.Unlock()
 
-		var b bytes.Buffer
-		b.WriteString("Daily Meeting scheduled on ")
+	if ok && (len(i.Days) > 0 || i.Schedule != "") {
 
-		for _, w := range i.Days {
-			b.WriteString(w.String() + ", ")
+		days := make([]string, len(i.Days))
+		for j, w := range i.Days {
+			days[j] = tr(m.getChannelID(), w.String())
 		}
 
-		if i.LimitTime.IsZero() {
-			message.Text = fmt.Sprintf(b.String()[:len(b.String())-2]+" at %02d:%02d",
+		if i.Schedule != "" {
+			message.Text = tr(m.getChannelID(), "Daily Meeting scheduled with `%s`", i.Schedule)
+		} else if i.LimitTime.IsZero() {
+			message.Text = tr(m.getChannelID(), "Daily Meeting scheduled on %s at %02d:%02d", strings.Join(days, ", "),
 				i.StartTime.Hour(), i.StartTime.Minute())
 		} else {
-			message.Text = fmt.Sprintf(b.String()[:len(b.String())-2]+" between %02d:%02d and %02d:%02d",
+			share := i.ActiveShare
+			if share <= 0 {
+				share = defaultActiveShare
+			}
+			message.Text = tr(m.getChannelID(), "Daily Meeting scheduled on %s between %02d:%02d and %02d:%02d, "+
+				"as soon as %d%% of the team is online", strings.Join(days, ", "),
 				i.StartTime.Hour(), i.StartTime.Minute(),
-				i.LimitTime.Hour(), i.LimitTime.Minute())
+				i.LimitTime.Hour(), i.LimitTime.Minute(), share)
+		}
+		if s, err := scheduler.ForDaily(i); err == nil {
+			if next := s.Next(time.Now()); !next.IsZero() {
+				message.Text += "\n" + tr(m.getChannelID(), "Next meeting on %s", formatDayTime(m.getChannelID(), next))
+			}
+		}
+		if i.Threaded {
+			message.Text += "\n" + tr(m.getChannelID(), "It will run inside a thread :thread:")
+		}
+		if i.Mood {
+			message.Text += "\n" + tr(m.getChannelID(), "Members will tell how they feel :thermometer:")
+		}
+		if i.Order != api.OrderDefault {
+			message.Text += "\n" + tr(m.getChannelID(), "Members will speak in %s order", tr(m.getChannelID(), i.Order))
 		}
 		if !i.LastDaily.IsZero() {
-			message.Text += fmt.Sprintf("\nLast meeting done %2.2f hours ago", time.Since(i.LastDaily).Hours())
+			message.Text += "\n" + tr(m.getChannelID(), "Last meeting done %2.2f hours ago",
+				time.Since(i.LastDaily).Hours())
 		}
 	}
 
-	if err := message.send(ws); err != nil {
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
-	channelsDailyMap.Unlock()
 }
 
-func manageAddReplyDaily(ws *websocket.Conn, m *Message) {
+func manageAddReplyDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
 
 	channelsMap.Lock()
 	if channelsMap.p[m.getChannelID()] == nil {
 		channelsMap.p[m.getChannelID()] = map[string]chan Message{}
 	}
-	if channelsMap.p[m.getChannelID()]["<@"+m.User+">"] == nil {
-		channelsMap.p[m.getChannelID()]["<@"+m.User+">"] = make(chan Message)
-		defer channelsMap.finishWaitingMember(m.getChannelID(), "<@"+m.User+">")
+	if channelsMap.p[m.getChannelID()][m.User] == nil {
+		channelsMap.p[m.getChannelID()][m.User] = make(chan Message)
+		defer channelsMap.finishWaitingMember(m.getChannelID(), m.User)
 	}
 	channelsMap.Unlock()
 
@@ -751,9 +1875,9 @@ func manageAddReplyDaily(ws *websocket.Conn, m *Message) {
 		Type:    "message",
 		User:    "",
 		Channel: m.getChannelID(),
-		Text:    "To what question I should reply? First one, second one or last one?",
+		Text:    tr(m.getChannelID(), "To what question I should reply? First one, second one or last one?"),
 	}
-	if err := message.send(ws); err != nil {
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 
@@ -761,10 +1885,10 @@ func manageAddReplyDaily(ws *websocket.Conn, m *Message) {
 	var question int
 
 	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 		if messageReceived.isCancel() {
 			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
 			return
@@ -775,25 +1899,27 @@ func manageAddReplyDaily(ws *websocket.Conn, m *Message) {
 			break
 		}
 
-		message.Text = ":scream: Type something like `first one`, `second one`, `last one` or `cancel`."
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `first one`, `second one`, `last one` or "+
+			"`cancel`.")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 
 	}
 
-	message.Text = "What is the regular expression which matches the answer of the team member to that question?"
-	if err := message.send(ws); err != nil {
+	message.Text = tr(m.getChannelID(), "What is the regular expression which matches the answer of the team member "+
+		"to that question?")
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 
 	var exp string
 
 	for {
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 		if messageReceived.isCancel() {
 			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
 			return
@@ -804,26 +1930,26 @@ func manageAddReplyDaily(ws *websocket.Conn, m *Message) {
 			break
 		}
 
-		message.Text = ":scream: I don't understand that regular expression. Who does? Try again! \n" +
-			"Type something like `It's /(?i)hello/` to match an answer like Hello, HELLO or hello world, " +
-			"and don't forget write it between / and / but don't start with / \n" +
-			"You may find some help in this website for help: https://regex101.com/"
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), ":scream: I don't understand that regular expression. Who does? Try again! \n"+
+			"Type something like `It's /(?i)hello/` to match an answer like Hello, HELLO or hello world, "+
+			"and don't forget write it between / and / but don't start with / \n"+
+			"You may find some help in this website for help: https://regex101.com/")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 	}
 
 	var match bool
 	for {
-		message.Text = "Should I reply when the member's answer match the regular expression?"
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), "Should I reply when the member's answer match the regular expression?")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 
-		messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+		messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 		if messageReceived.isCancel() {
 			message.Text = ":ok_hand:"
-			if err := message.send(ws); err != nil {
+			if err := message.send(chat); err != nil {
 				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 			}
 			return
@@ -834,24 +1960,24 @@ func manageAddReplyDaily(ws *websocket.Conn, m *Message) {
 			break
 		}
 
-		message.Text = ":scream: Type something like `yes`, `no` or `cancel`\n" +
-			"If you type `no`, I will reply only if regular expression *doesn't match* the answer\n" +
-			"If you type `yes`, only if regular expression *match* the answer\n"
-		if err := message.send(ws); err != nil {
+		message.Text = tr(m.getChannelID(), ":scream: Type something like `yes`, `no` or `cancel`\n"+
+			"If you type `no`, I will reply only if regular expression *doesn't match* the answer\n"+
+			"If you type `yes`, only if regular expression *match* the answer\n")
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 
 	}
 
-	message.Text = "What do I should reply to the question?"
-	if err := message.send(ws); err != nil {
+	message.Text = tr(m.getChannelID(), "What do I should reply to the question?")
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 
-	messageReceived = <-channelsMap.p[m.getChannelID()]["<@"+m.User+">"]
+	messageReceived = <-channelsMap.p[m.getChannelID()][m.User]
 	if messageReceived.isCancel() {
 		message.Text = ":ok_hand:"
-		if err := message.send(ws); err != nil {
+		if err := message.send(chat); err != nil {
 			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 		}
 		return
@@ -869,21 +1995,25 @@ func manageAddReplyDaily(ws *websocket.Conn, m *Message) {
 
 	if err := addPredefinedReply(&replyToAdd); err != nil {
 		log.Printf("slackutils: error storing predefined reply from channel %s: %s\n", m.getChannelID(), err)
-		sendUnexpectedProblemMsj(ws, m.getChannelID())
+		sendUnexpectedProblemMsj(chat, m.getChannelID())
 		return
 	}
 
-	message.Text = "Yeah! I will do it as you've requested :smiling_imp:"
-	if err := message.send(ws); err != nil {
+	message.Text = tr(m.getChannelID(), "Yeah! I will do it as you've requested :smiling_imp:")
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending msj to channel %s: %s\n", m.getChannelID(), err)
 	}
 }
 
-func manageDeleteReplyDaily(ws *websocket.Conn, m *Message) {
+func manageDeleteReplyDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
 
 	if err := delPredefinedReplies(m.getChannelID()); err != nil {
 		log.Printf("slackutils: error deleting predefined replies from channel %s: %s\n", m.getChannelID(), err)
-		sendUnexpectedProblemMsj(ws, m.getChannelID())
+		sendUnexpectedProblemMsj(chat, m.getChannelID())
 		return
 	}
 
@@ -892,14 +2022,14 @@ func manageDeleteReplyDaily(ws *websocket.Conn, m *Message) {
 		Type:    "message",
 		User:    "",
 		Channel: m.getChannelID(),
-		Text:    "Predefined replies deleted in this channel :+1:",
+		Text:    tr(m.getChannelID(), "Predefined replies deleted in this channel :+1:"),
 	}
-	if err := message.send(ws); err != nil {
+	if err := message.send(chat); err != nil {
 		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
 	}
 }
 
-func isExpectedMessage(m *Message) bool {
+func isExpectedMessage(chat ChatAdapter, m *Message) bool {
 	switch {
 	case m.Type != "message":
 		return false
@@ -907,111 +2037,200 @@ func isExpectedMessage(m *Message) bool {
 		return false
 	case channelsMap.isMemberAwaited(m.getChannelID(), m.User):
 		return true
+	case m.Direct && channelsMap.isMemberAwaited(directChannel, m.User):
+		return true
 	default:
 		return false
 	}
 }
 
-func manageExpectedMessage(ws *websocket.Conn, m *Message) {
-	channelsMap.Lock()
-	channelsMap.p[m.getChannelID()]["<@"+m.User+">"] <- *m
-	channelsMap.Unlock()
+func manageExpectedMessage(chat ChatAdapter, m *Message) {
+	// Answers in private go to the conversation awaiting the member in any direct channel
+	channelID := m.getChannelID()
+	if m.Direct && !channelsMap.isMemberAwaited(channelID, m.User) {
+		channelID = directChannel
+	}
+
+	channelsMap.deliver(channelID, *m)
 }
 
 // Messages to send
 
-func sendHelloMsj(ws *websocket.Conn, channelID string) error {
+func sendHelloMsj(chat ChatAdapter, channelID string) error {
 
 	m := &Message{
 		ID:      0,
 		Type:    "message",
 		Channel: channelID,
-		Text: "Hello team! I'm here to help you with your daily meetings. To add members " +
-			"to the daily meeting type `@leanmanager daily add member`, to setup the hour of the " +
-			"daily meeting, type `@leanmanager daily schedule`.\n" +
-			"If you need help, just type `@leanmanager help` :sos:",
+		Text:    render(channelID, "hello", nil),
 	}
 
-	return m.send(ws)
+	return m.send(chat)
 }
 
-func sendHelpMsj(ws *websocket.Conn, channelID string) error {
+// helpLines are the orders listed in the help, translated one by one
+var helpLines = []string{
+	"`@leanmanager daily add member` to add new members in the Daily Meeting",
+	"`@leanmanager daily role @member admin|member|observer` to change who configures or speaks in the Daily",
+	"`@leanmanager daily add all` to add all the members of the channel in the Daily Meeting",
+	"`@leanmanager daily delete member` to delete members from the Daily Meeting",
+	"`@leanmanager daily list members` to obtain a list of members participating in the Daily",
+	"`@leanmanager daily start` to start the daily in any moment or to repeat it",
+	"`@leanmanager daily info` to know when it's scheduled and the last time it was done",
+	"`@leanmanager daily schedule` to setup the periodicity of the Daily Meeting",
+	"`@leanmanager daily schedule 0 9 * * 1-5` to schedule it with a cron expression or a RRULE",
+	"`@leanmanager daily threaded` to run the Daily Meeting inside a single thread",
+	"`@leanmanager daily mood` to ask how everyone feels, `daily mood report` to see the chart of the week",
+	"`@leanmanager daily content <feed URL>|<quotes file>|off` to end the Daily Meeting with a joke, a comic or a quote",
+	"`@leanmanager daily language en|es|gl` to choose the language I speak in this channel",
+	"`@leanmanager daily persona Marvin :robot_face:` to change my name and icon in this channel",
+	"`@leanmanager daily timeout` to setup how long I wait for the members' answers",
+	"`@leanmanager daily order random|rotation|alphabetical|manual` to choose who speaks first",
+	"`@leanmanager daily resume` to do the Daily report if you miss the Daily Meeting, also in private",
+	"`@leanmanager daily pending` to know who still owes today's Daily report",
+	"`@leanmanager daily skip @member` to skip a member, only for today's facilitator or an admin",
+	"`@leanmanager daily snooze 15m` to pause the Daily Meeting and go on later",
+	"`@leanmanager daily end` to end the Daily Meeting, `daily stop` to abort it",
+	"`@leanmanager meeting add retrospective 0 15 * * 5 with @member` to schedule other meetings",
+	"`@leanmanager meeting list`, `meeting start <id>` and `meeting delete <id>` to manage them",
+	"`@leanmanager action list` and `action done <id>` to follow the action items of the Retrospectives",
+	"`@leanmanager remind @member|#channel \"text\" every friday 16:00` or `on 2026-11-02 09:00` to add reminders",
+	"`@leanmanager remind list` and `remind delete <id>` to manage them",
+	"`@leanmanager timesheet schedule friday 16:00` and `timesheet hours 40` to ask for the weekly timesheets",
+	"`@leanmanager timesheet fill` to fill yours now, `timesheet report` to see who is missing",
+	"`@leanmanager morning schedule weekdays 8:30` to greet the team with the agenda of the day, `morning` to see it now",
+	"`@leanmanager absent me|@member 2026-10-20 [2026-10-23]` to add absences, `absent list` and `absent delete <id>`",
+	"`@leanmanager daily add reply` to add predefined bot replies to the Daily answers",
+	"`@leanmanager daily delete reply` to delete predefined bot replies to the Daily answers",
+	"Or just ask me, like `@leanmanager schedule the daily at 9:30 on weekdays` or " +
+		"`@leanmanager add @member to the daily`",
+}
+
+func sendHelpMsj(chat ChatAdapter, channelID string) error {
+
+	commands := make([]string, len(helpLines))
+	for i, l := range helpLines {
+		commands[i] = tr(channelID, l)
+	}
 
 	m := &Message{
 		ID:      0,
 		Type:    "message",
 		Channel: channelID,
-		Text: "Even if I'm a bit surly, work with me it's quite easy :sunglasses:\n" +
-			"Just type the order, and I will obey. Those are the orders available:\n" +
-			"`@leanmanager daily add member` to add new members in the Daily Meeting\n" +
-			"`@leanmanager daily delete member` to delete members from the Daily Meeting\n" +
-			"`@leanmanager daily list members` to obtain a list of members participating in the Daily\n" +
-			"`@leanmanager daily start` to start the daily in any moment or to repeat it\n" +
-			"`@leanmanager daily info` to know when it's scheduled and the last time it was done\n" +
-			"`@leanmanager daily schedule` to setup the periodicity of the Daily Meeting\n" +
-			"`@leanmanager daily resume` to do the Daily report if you miss the Daily Meeting\n" +
-			"`@leanmanager daily add reply` to add predefined bot replies to the Daily answers\n" +
-			"`@leanmanager daily delete reply` to delete predefined bot replies to the Daily answers\n" +
-			"If I ask something, just reply, I will do my best to understand you :grin:",
+		Text:    render(channelID, "help", map[string]interface{}{"Commands": strings.Join(commands, "\n")}),
 	}
 
-	return m.send(ws)
+	return m.send(chat)
 }
 
-func sendStartDailyMsj(ws *websocket.Conn, channelID string) error {
+// sendStartDailyMsj announces the Daily Meeting and, if threaded, returns the ts of the thread to reply in
+func sendStartDailyMsj(chat ChatAdapter, channelID string, threaded bool, facilitator string) (threadTS string,
+	err error) {
 	m := &Message{
 		ID:      0,
 		Type:    "message",
 		Channel: channelID,
-		Text:    "Hi @channel! Let's start the Daily Meeting :mega:",
 	}
-	return m.send(ws)
+	data := map[string]interface{}{"Facilitator": ""}
+	if facilitator != "" {
+		data["Facilitator"] = chat.Mention(facilitator)
+	}
+	m.Text = render(channelID, "daily-start", data)
+	if !threaded {
+		return "", m.send(chat)
+	}
+	return m.sendWithAck(chat)
+}
+
+func sendNotAvailableMsj(chat ChatAdapter, channelID, threadTS string) error {
+	m := &Message{
+		ID:       0,
+		Type:     "message",
+		Channel:  channelID,
+		Text:     render(channelID, "daily-not-available", nil),
+		ThreadTS: threadTS,
+	}
+	return m.send(chat)
+}
+
+func sendAnswerTimeoutMsj(chat ChatAdapter, channelID, askChannelID, memberID, threadTS string) error {
+	m := &Message{
+		ID:       0,
+		Type:     "message",
+		Channel:  askChannelID,
+		Text:     render(channelID, "daily-answer-timeout", map[string]interface{}{"Member": chat.Mention(memberID)}),
+		ThreadTS: threadTS,
+	}
+	m.Username, m.Icon = getPersona(channelID)
+	return m.send(chat)
+}
+
+func sendNudgeMsj(chat ChatAdapter, channelID, memberID string) error {
+	return chat.SendDirect(memberID, tr(channelID, "Hey! The meeting is waiting for your answer :bell:"))
 }
 
-func sendNotAvailableMsj(ws *websocket.Conn, channelID string) error {
+func sendNotMembersRegisteredMsj(chat ChatAdapter, channelID string) error {
 	m := &Message{
 		ID:      0,
 		Type:    "message",
 		Channel: channelID,
-		Text:    ":chicken:... please, do it later, just type `@leanmanager daily resume` before the end of the day",
+		Text:    render(channelID, "no-members", nil),
 	}
-	return m.send(ws)
+	return m.send(chat)
 }
 
-func sendNotMembersRegisteredMsj(ws *websocket.Conn, channelID string) error {
+func sendNoAdminMsj(chat ChatAdapter, channelID string) error {
 	m := &Message{
 		ID:      0,
 		Type:    "message",
 		Channel: channelID,
-		Text:    "There are no members registered yet. Type `@leanmanager daily add member` to add the first one",
+		Text:    render(channelID, "no-admin", nil),
 	}
-	return m.send(ws)
+	return m.send(chat)
 }
-func sendUnexpectedProblemMsj(ws *websocket.Conn, channelID string) error {
+
+func sendUnexpectedProblemMsj(chat ChatAdapter, channelID string) error {
 	m := &Message{
 		ID:      0,
 		Type:    "message",
 		Channel: channelID,
-		Text: "It was an unexpected behaviour, I don't have idea what's going to happen now... so you can " +
-			"wait and see what happens or contact support@leanmanager.eu asking for help",
+		Text:    render(channelID, "unexpected-problem", nil),
 	}
-	return m.send(ws)
+	return m.send(chat)
 }
 
 // Message methods
 
-func (m Message) send(ws *websocket.Conn) error {
+func (m Message) send(chat ChatAdapter) error {
 	m.ID = counter.add(1)
-	return websocket.JSON.Send(ws, m)
+	m.setPersona()
+	return chat.Send(m)
+}
+
+// sendWithAck sends the message and waits until the chat confirms it, returning the ts assigned to it
+func (m Message) sendWithAck(chat ChatAdapter) (string, error) {
+	m.ID = counter.add(1)
+	m.setPersona()
+	return chat.SendWithAck(m)
+}
+
+// setPersona signs the message with the name and icon of the bot in its channel, if it has its own
+func (m *Message) setPersona() {
+	if channelID, ok := m.Channel.(string); ok && m.Username == "" && m.Icon == "" {
+		m.Username, m.Icon = getPersona(channelID)
+	}
 }
 
 func (m Message) String() string {
 	return fmt.Sprintf("Channel: %s, Type: %s, User: %s, ID: %d, Message: %s", m.Channel, m.Type, m.User, m.ID, m.Text)
 }
 func (m Message) getChannelID() string {
-	if m.Type == "message" {
+	if m.Type == "message" || m.Type == "member_left_channel" || m.Type == "member_joined_channel" {
 		return m.Channel.(string)
 	}
+	if (m.Type == "reaction_added" || m.Type == "reaction_removed") && m.Item != nil {
+		return m.Item.Channel
+	}
 	if m.Type == "group_joined" || m.Type == "channel_joined" {
 		t := m.Channel.(map[string]interface{})
 		for k, v := range t {
@@ -1026,8 +2245,17 @@ func (m Message) getChannelID() string {
 	return ""
 }
 
-func (m Message) isHelpMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> help") ||
+func (m Message) isRoleDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily role") ||
+		strings.HasPrefix(m.Text, "leanmanager daily role")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isHelpMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" help") ||
 		strings.HasPrefix(m.Text, "leanmanager help")) {
 		return true
 	}
@@ -1035,12 +2263,12 @@ func (m Message) isHelpMsj(botID string) bool {
 	return false
 }
 
-func (m Message) isInitialMsj(botID string) bool {
+func (m Message) isInitialMsj(botMention string) bool {
 	if m.Type == "group_joined" || m.Type == "channel_joined" {
 		return true
 	}
 
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> hello") ||
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" hello") ||
 		strings.HasPrefix(m.Text, "leanmanager hello")) {
 		return true
 	}
@@ -1048,8 +2276,8 @@ func (m Message) isInitialMsj(botID string) bool {
 	return false
 }
 
-func (m Message) isAddMemberDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily add member") ||
+func (m Message) isAddMemberDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily add member") ||
 		strings.HasPrefix(m.Text, "leanmanager daily add member")) {
 		return true
 	}
@@ -1057,99 +2285,220 @@ func (m Message) isAddMemberDailyMsj(botID string) bool {
 	return false
 }
 
-func (m Message) isDeleteMemberDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily delete member") ||
+func (m Message) isAddAllMembersDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily add all") ||
+		strings.HasPrefix(m.Text, "leanmanager daily add all")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isBotJoinedMsj(botID string) bool {
+	return m.Type == "member_joined_channel" && m.User == botID
+}
+
+func (m Message) isMemberLeftMsj() bool {
+	return m.Type == "member_left_channel" && m.User != ""
+}
+
+func (m Message) isDeleteMemberDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily delete member") ||
 		strings.HasPrefix(m.Text, "leanmanager daily delete member")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isListMembersDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily list") ||
+func (m Message) isListMembersDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily list") ||
 		strings.HasPrefix(m.Text, "leanmanager daily list")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isStartDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily start") ||
+func (m Message) isStartDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily start") ||
 		strings.HasPrefix(m.Text, "leanmanager daily start")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isInfoDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily info") ||
+func (m Message) isInfoDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily info") ||
 		strings.HasPrefix(m.Text, "leanmanager daily info")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isAddReplyDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily add reply") ||
+func (m Message) isAddReplyDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily add reply") ||
 		strings.HasPrefix(m.Text, "leanmanager daily add reply")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isDeleteReplyDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily delete reply") ||
+func (m Message) isDeleteReplyDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily delete reply") ||
 		strings.HasPrefix(m.Text, "leanmanager daily delete reply")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isScheduleDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily schedule") ||
+func (m Message) isScheduleDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily schedule") ||
 		strings.HasPrefix(m.Text, "leanmanager daily schedule")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isResumeDailyMsj(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> daily resume") ||
-		strings.HasPrefix(m.Text, "leanmanager daily resume")) {
+func (m Message) isThreadedDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily threaded") ||
+		strings.HasPrefix(m.Text, "leanmanager daily threaded")) {
+		return true
+	}
+	return false
+}
+
+func (m Message) isContentDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily content") ||
+		strings.HasPrefix(m.Text, "leanmanager daily content")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isTimeoutDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily timeout") ||
+		strings.HasPrefix(m.Text, "leanmanager daily timeout")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isOrderDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily order") ||
+		strings.HasPrefix(m.Text, "leanmanager daily order")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isSkipDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily skip") ||
+		strings.HasPrefix(m.Text, "leanmanager daily skip")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isSnoozeDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily snooze") ||
+		strings.HasPrefix(m.Text, "leanmanager daily snooze")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isStopDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily stop") ||
+		strings.HasPrefix(m.Text, "leanmanager daily stop")) {
+		return true
+	}
+
+	return false
+}
+
+// getValidSnooze returns how long the Daily Meeting is snoozed, e.g. `daily snooze 15m` or `daily snooze 10`
+func (m Message) getValidSnooze() (time.Duration, error) {
+	args := strings.TrimSpace(m.Text[strings.Index(m.Text, "daily snooze")+len("daily snooze"):])
+	if args == "" {
+		return defaultSnooze, nil
+	}
+
+	arg := strings.Fields(args)[0]
+	if _, err := strconv.Atoi(arg); err == nil {
+		arg += "m"
+	}
+
+	snooze, err := time.ParseDuration(arg)
+	if err != nil {
+		return 0, err
+	}
+	if snooze <= 0 || snooze > maxSnooze {
+		return 0, fmt.Errorf("snooze out of range")
+	}
+
+	return snooze, nil
+}
+
+func (m Message) isEndDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily end") ||
+		strings.HasPrefix(m.Text, "leanmanager daily end")) {
+		return true
+	}
+
+	return false
+}
+
+func (m Message) isResumeDailyMsj(botMention string) bool {
+	// In a direct message there is no need to mention the bot
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily resume") ||
+		strings.HasPrefix(m.Text, "leanmanager daily resume") ||
+		m.Direct && strings.HasPrefix(m.Text, "daily resume")) {
 		return true
 	}
 	return false
 }
 
-func (m Message) isCommand(botID string) bool {
-	if m.Type == "message" && (strings.HasPrefix(m.Text, "<@"+botID+"> ") ||
-		strings.HasPrefix(m.Text, "leanmanager ")) {
+func (m Message) isPendingDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily pending") ||
+		strings.HasPrefix(m.Text, "leanmanager daily pending")) {
 		return true
 	}
 	return false
 }
 
+func (m Message) isCommand(botMention string) bool {
+	_, ok := m.getCommandText(botMention)
+	return ok
+}
+
+// isInThread returns true when the message belongs to the thread, any message does if there is no thread
+func (m Message) isInThread(threadTS string) bool {
+	return threadTS == "" || m.ThreadTS == threadTS
+}
+
 func (m Message) isYes() bool {
 	if m.Type != "message" {
 		return false
 	}
-	return strings.EqualFold(m.Text, "si") || strings.EqualFold(m.Text, "yes") ||
-		strings.EqualFold(m.Text, "sip") || strings.EqualFold(m.Text, "sí") ||
-		strings.EqualFold(m.Text, "yeah") || strings.EqualFold(m.Text, "ok")
+	return i18n.Is("yes", m.Text)
 }
 
 func (m Message) isNo() bool {
 	if m.Type != "message" {
 		return false
 	}
-	return strings.EqualFold(m.Text, "no") || strings.EqualFold(m.Text, "nop")
+	return i18n.Is("no", m.Text)
 }
 
 func (m Message) isCancel() bool {
 	if m.Type != "message" {
 		return false
 	}
-	return strings.EqualFold(m.Text, "cancel")
+	return i18n.Is("cancel", m.Text)
 }
 
 func (m Message) getValidDays() (doW []time.Weekday) {
@@ -1157,11 +2506,12 @@ func (m Message) getValidDays() (doW []time.Weekday) {
 		return nil
 	}
 
-	re := regexp.MustCompile("(?i)(every|week|mon|tues|wednes|thurs|fri|satur|sun)[d][a][y]s?")
-	days := re.FindAllString(m.Text, 7)
+	// The days are understood in any of the supported languages
+	words := strings.FieldsFunc(m.Text, func(r rune) bool { return !unicode.IsLetter(r) })
 
-	for _, d := range days {
-		switch strings.ToLower(d) {
+	for _, w := range words {
+		switch i18n.Kind(w, "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
+			"weekdays", "everyday") {
 		case "monday":
 			doW = append(doW, time.Monday)
 		case "tuesday":
@@ -1176,7 +2526,7 @@ func (m Message) getValidDays() (doW []time.Weekday) {
 			doW = append(doW, time.Saturday)
 		case "sunday":
 			doW = append(doW, time.Sunday)
-		case "weekday", "weekdays":
+		case "weekdays":
 			doW = append(doW, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday,
 				time.Thursday, time.Friday}...)
 		case "everyday":
@@ -1197,13 +2547,22 @@ func (m Message) getValidHour() string {
 	return re.FindString(m.Text)
 }
 
-func (m Message) getValidUserIDs() []string {
+func (m Message) getValidUserIDs(chat ChatAdapter) []string {
 	if m.Type != "message" {
 		return nil
 	}
 
-	re := regexp.MustCompile("(?i)[<][@][A-Za-z0-9]*[>]")
-	return re.FindAllString(m.Text, -1)
+	return chat.ParseMentions(m.Text)
+}
+
+// getCommandUserIDs returns the members mentioned after the command, e.g. `daily add member @alice`
+func (m Message) getCommandUserIDs(chat ChatAdapter, command string) []string {
+	i := strings.Index(m.Text, command)
+	if m.Type != "message" || i < 0 {
+		return nil
+	}
+
+	return chat.ParseMentions(m.Text[i+len(command):])
 }
 
 func (m Message) getPredefinedReply(q int) string {
@@ -1252,18 +2611,81 @@ func (m Message) getValidAnswer() (int, error) {
 		return -1, fmt.Errorf("no type message")
 	}
 
-	re := regexp.MustCompile("(?i)([f][i][r][s][t]|[s][e][c][o][n][d]|[l][a][s][t])")
-	switch strings.ToLower(re.FindString(m.Text)) {
-	case "first":
-		return 0, nil
-	case "second":
-		return 1, nil
-	case "last":
-		return 2, nil
+	for _, w := range strings.FieldsFunc(m.Text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		switch i18n.Kind(w, "first", "second", "last") {
+		case "first":
+			return 0, nil
+		case "second":
+			return 1, nil
+		case "last":
+			return 2, nil
+		}
 	}
 	return -1, fmt.Errorf("question not found")
 }
 
+// getValidOrder returns the speaking order typed after `daily order`, or "" if there isn't a valid one
+func (m Message) getValidOrder() string {
+	if m.Type != "message" {
+		return ""
+	}
+
+	re := regexp.MustCompile("(?i)daily order\\s+(random|rotation|alphabetical|manual)\\b")
+	match := re.FindStringSubmatch(m.Text)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
+
+// getValidPercentage parses shares like 60 or 60%, between 1 and 100
+func (m Message) getValidPercentage() (int, error) {
+	if m.Type != "message" {
+		return -1, fmt.Errorf("no type message")
+	}
+
+	re := regexp.MustCompile("^\\s*([0-9]+)\\s*%?\\s*$")
+	match := re.FindStringSubmatch(m.Text)
+	if match == nil {
+		return -1, fmt.Errorf("percentage not found")
+	}
+
+	share, err := strconv.Atoi(match[1])
+	if err != nil {
+		return -1, err
+	}
+	if share < 1 || share > 100 {
+		return -1, fmt.Errorf("percentage out of range")
+	}
+
+	return share, nil
+}
+
+// getValidMinutes parses durations like 5, 10m or 1h, returning minutes
+func (m Message) getValidMinutes() (int, error) {
+	if m.Type != "message" {
+		return -1, fmt.Errorf("no type message")
+	}
+
+	re := regexp.MustCompile("(?i)^\\s*([0-9]+)\\s*(m|min|mins|minutes|h|hour|hours)?\\s*$")
+	match := re.FindStringSubmatch(m.Text)
+	if match == nil {
+		return -1, fmt.Errorf("minutes not found")
+	}
+
+	minutes, err := strconv.Atoi(match[1])
+	if err != nil {
+		return -1, err
+	}
+	if strings.HasPrefix(strings.ToLower(match[2]), "h") {
+		minutes *= 60
+	}
+	if minutes < 1 || minutes > 8*60 {
+		return -1, fmt.Errorf("minutes out of range")
+	}
+	return minutes, nil
+}
+
 func (m Message) getValidRegularExpression() (string, error) {
 	if m.Type != "message" {
 		return "", fmt.Errorf("no type message")
@@ -1293,7 +2715,7 @@ func (pe *pendingMsjController) isMemberAwaited(channelID, memberID string) bool
 		return false
 	}
 
-	if pe.p[channelID]["<@"+memberID+">"] != nil {
+	if pe.p[channelID][memberID] != nil {
 		return true
 	}
 	return false
@@ -1302,9 +2724,232 @@ func (pe *pendingMsjController) isMemberAwaited(channelID, memberID string) bool
 
 func (pe *pendingMsjController) finishWaitingMember(channelID, memberID string) {
 	pe.Lock()
-	close(channelsMap.p[channelID][memberID])
-	delete(channelsMap.p[channelID], memberID)
+	c := pe.p[channelID][memberID]
+	delete(pe.p[channelID], memberID)
+	pe.Unlock()
+
+	// Deliveries in progress end before the channel is closed, the next ones don't find it
+	pe.delivering.Lock()
+	close(c)
+	pe.delivering.Unlock()
+}
+
+// deliver gives the message to the conversation waiting for the member. The conversations are only locked to find
+// the channel, so they aren't blocked while the wait may have just expired
+func (pe *pendingMsjController) deliver(channelID string, m Message) {
+	pe.delivering.RLock()
+	defer pe.delivering.RUnlock()
+
+	// A channel still registered isn't closed until the delivery ends
+	pe.Lock()
+	c := pe.p[channelID][m.User]
 	pe.Unlock()
+	if c == nil {
+		return
+	}
+
+	select {
+	case c <- m:
+	case <-time.After(time.Second):
+		log.Printf("slackutils: message of %s in channel %s discarded, nobody is waiting for it\n",
+			m.User, channelID)
+	}
+}
+
+// receiveInThread waits for the next member's message in askChannelID, discarding those posted outside the thread.
+// The member is nudged in private, in the language of channelID, halfway through the limit. errAnswerTimeout is
+// returned if it expires and errTurnInterrupted if interrupt is closed
+func (pe *pendingMsjController) receiveInThread(chat ChatAdapter, channelID, askChannelID, memberID, threadTS string,
+	limit time.Duration, interrupt <-chan struct{}) (Message, error) {
+
+	c := pe.p[askChannelID][memberID]
+	nudge := time.After(limit / 2)
+	expired := time.After(limit)
+	for {
+		select {
+		case m := <-c:
+			if m.isInThread(threadTS) {
+				return m, nil
+			}
+		case <-nudge:
+			if err := sendNudgeMsj(chat, channelID, memberID); err != nil {
+				log.Printf("slackutils: error nudging member %s: %s\n", memberID, err)
+			}
+		case <-expired:
+			return Message{}, errAnswerTimeout
+		case <-interrupt:
+			return Message{}, errTurnInterrupted
+		}
+	}
+}
+
+func (pr *pendingResumeController) add(channelID, memberID string) {
+	pr.Lock()
+	defer pr.Unlock()
+	if pr.p[channelID] == nil {
+		pr.p[channelID] = map[string]bool{}
+	}
+	pr.p[channelID][memberID] = true
+}
+
+func (pr *pendingResumeController) isPending(channelID, memberID string) bool {
+	pr.Lock()
+	defer pr.Unlock()
+	return pr.p[channelID][memberID]
+}
+
+// finish removes the pending resume, returning true if it was still pending
+func (pr *pendingResumeController) finish(channelID, memberID string) bool {
+	pr.Lock()
+	defer pr.Unlock()
+	pending := pr.p[channelID][memberID]
+	delete(pr.p[channelID], memberID)
+	return pending
+}
+
+// start registers the Daily Meeting as running, returning false if it already was
+func (rd *runningDailyController) start(channelID string) bool {
+	rd.Lock()
+	defer rd.Unlock()
+	if rd.r[channelID] != nil {
+		return false
+	}
+	rd.r[channelID] = &runningDaily{skipped: map[string]bool{}, wake: make(chan struct{})}
+	return true
+}
+
+func (rd *runningDailyController) finish(channelID string) {
+	rd.Lock()
+	defer rd.Unlock()
+	delete(rd.r, channelID)
+}
+
+func (rd *runningDailyController) setFacilitator(channelID, memberID string) {
+	rd.Lock()
+	defer rd.Unlock()
+	if r := rd.r[channelID]; r != nil {
+		r.facilitator = memberID
+	}
+}
+
+func (rd *runningDailyController) getFacilitator(channelID string) (memberID string, running bool) {
+	rd.Lock()
+	defer rd.Unlock()
+	if r := rd.r[channelID]; r != nil {
+		return r.facilitator, true
+	}
+	return "", false
+}
+
+// turn gives the turn to the member, returning the channel closed if the turn is interrupted or false if the
+// member has been skipped or the meeting ended
+func (rd *runningDailyController) turn(channelID, memberID string) (<-chan struct{}, bool) {
+	rd.Lock()
+	defer rd.Unlock()
+	r := rd.r[channelID]
+	if r == nil || r.ended || r.skipped[memberID] {
+		return nil, false
+	}
+	r.current = memberID
+	r.interrupt = make(chan struct{})
+	return r.interrupt, true
+}
+
+func (rd *runningDailyController) skip(channelID, memberID string) {
+	rd.Lock()
+	defer rd.Unlock()
+	r := rd.r[channelID]
+	if r == nil {
+		return
+	}
+	r.skipped[memberID] = true
+	if r.current == memberID {
+		r.interruptTurn()
+	}
+}
+
+func (rd *runningDailyController) end(channelID string) {
+	rd.Lock()
+	defer rd.Unlock()
+	r := rd.r[channelID]
+	if r == nil || r.ended {
+		return
+	}
+	r.ended = true
+	r.interruptTurn()
+	close(r.wake)
+}
+
+// stop ends the meeting without the usual ending
+func (rd *runningDailyController) stop(channelID string) {
+	rd.Lock()
+	if r := rd.r[channelID]; r != nil {
+		r.stopped = true
+	}
+	rd.Unlock()
+	rd.end(channelID)
+}
+
+func (rd *runningDailyController) isEnded(channelID string) bool {
+	rd.Lock()
+	defer rd.Unlock()
+	r := rd.r[channelID]
+	return r == nil || r.ended
+}
+
+func (rd *runningDailyController) isStopped(channelID string) bool {
+	rd.Lock()
+	defer rd.Unlock()
+	r := rd.r[channelID]
+	return r != nil && r.stopped
+}
+
+// snooze pauses the meeting until the time given, the current turn is interrupted to be repeated after it
+func (rd *runningDailyController) snooze(channelID string, until time.Time) {
+	rd.Lock()
+	defer rd.Unlock()
+	r := rd.r[channelID]
+	if r == nil {
+		return
+	}
+	r.snoozedUntil = until
+	if r.interrupt != nil {
+		r.repeat = true
+		r.interruptTurn()
+	}
+}
+
+// waitSnooze ends the current turn and blocks until the snooze is over or the meeting ends. It returns true in
+// repeat if the turn was interrupted by the snooze, and in snoozed if it has waited
+func (rd *runningDailyController) waitSnooze(channelID string) (repeat, snoozed bool) {
+	rd.Lock()
+	r := rd.r[channelID]
+	if r == nil {
+		rd.Unlock()
+		return false, false
+	}
+	repeat, r.repeat = r.repeat, false
+	r.current = ""
+	r.interrupt = nil
+	until, wake := r.snoozedUntil, r.wake
+	rd.Unlock()
+
+	if !time.Now().Before(until) {
+		return repeat, false
+	}
+
+	select {
+	case <-time.After(time.Until(until)):
+	case <-wake:
+	}
+	return repeat, true
+}
+
+func (r *runningDaily) interruptTurn() {
+	if r.interrupt != nil {
+		close(r.interrupt)
+		r.interrupt = nil
+	}
 }
 
 func (ac *atomicCounter) add(i uint64) uint64 {
@@ -0,0 +1,227 @@
+package slackbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+// fakeAPIServer keeps the members of the channels in memory, the other resources aren't found
+type fakeAPIServer struct {
+	sync.Mutex
+	members map[string]map[string]api.Member
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && path[0] == "channels":
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPost && path[0] == "members":
+		var m api.Member
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if f.members[m.ChannelID] == nil {
+			f.members[m.ChannelID] = map[string]api.Member{}
+		}
+		f.members[m.ChannelID][m.ID] = m
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && path[0] == "members" && len(path) == 2:
+		members := []api.Member{}
+		for _, m := range f.members[path[1]] {
+			members = append(members, m)
+		}
+		json.NewEncoder(w).Encode(members)
+	case r.Method == http.MethodGet && path[0] == "members" && len(path) == 3:
+		m, ok := f.members[path[1]][path[2]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(m)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newFakeAPIServer points the bot to an empty fakeAPIServer until the test ends
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	f := &fakeAPIServer{members: map[string]map[string]api.Member{}}
+	server := httptest.NewServer(f)
+
+	oldURL, oldClient := apiserverURL, apiClient
+	apiserverURL, apiClient = server.URL, server.Client()
+	t.Cleanup(func() {
+		apiserverURL, apiClient = oldURL, oldClient
+		server.Close()
+	})
+	return f
+}
+
+func (f *fakeAPIServer) admins(channelID string) []string {
+	f.Lock()
+	defer f.Unlock()
+
+	var admins []string
+	for _, m := range f.members[channelID] {
+		if m.IsAdmin() {
+			admins = append(admins, m.ID)
+		}
+	}
+	return admins
+}
+
+func TestInviterIsTheFirstAdmin(t *testing.T) {
+	f := newFakeAPIServer(t)
+	var out bytes.Buffer
+	chat := NewSimulatorChat(strings.NewReader(""), &out, "C1")
+
+	manageBotJoined(chat, &Message{Type: "member_joined_channel", User: simulatorBotID, Channel: "C1", Inviter: "U2"})
+	if got := f.admins("C1"); len(got) != 1 || got[0] != "U2" {
+		t.Fatalf("admins after U2 invited the bot = %v, want [U2]", got)
+	}
+
+	// Inviting the bot again doesn't give the channel to someone else
+	manageBotJoined(chat, &Message{Type: "member_joined_channel", User: simulatorBotID, Channel: "C1", Inviter: "U3"})
+	if got := f.admins("C1"); len(got) != 1 || got[0] != "U2" {
+		t.Errorf("admins after U3 invited the bot again = %v, want [U2]", got)
+	}
+
+	if checkAdmin(chat, &Message{Type: "message", User: simulatorCreatorID, Channel: "C1"}) {
+		t.Errorf("checkAdmin of the creator %s = true, want false when %s invited the bot", simulatorCreatorID, "U2")
+	}
+	if !checkAdmin(chat, &Message{Type: "message", User: "U2", Channel: "C1"}) {
+		t.Errorf("checkAdmin of the inviter U2 = false, want true")
+	}
+}
+
+func TestCreatorIsTheAdminWithoutInviter(t *testing.T) {
+	f := newFakeAPIServer(t)
+	var out bytes.Buffer
+	chat := NewSimulatorChat(strings.NewReader(""), &out, "C1")
+
+	manageBotJoined(chat, &Message{Type: "member_joined_channel", User: simulatorBotID, Channel: "C1"})
+	if got := f.admins("C1"); len(got) != 0 {
+		t.Fatalf("admins without inviter = %v, want none until a configuration command", got)
+	}
+
+	if checkAdmin(chat, &Message{Type: "message", User: "U3", Channel: "C1"}) {
+		t.Errorf("checkAdmin of U3 = true, want false as %s created the channel", simulatorCreatorID)
+	}
+	if got := f.admins("C1"); len(got) != 1 || got[0] != simulatorCreatorID {
+		t.Fatalf("admins after the first configuration command = %v, want [%s]", got, simulatorCreatorID)
+	}
+	if !checkAdmin(chat, &Message{Type: "message", User: simulatorCreatorID, Channel: "C1"}) {
+		t.Errorf("checkAdmin of the creator %s = false, want true", simulatorCreatorID)
+	}
+	if !strings.Contains(out.String(), "is the admin of the Daily Meeting") {
+		t.Errorf("the channel wasn't told who is the admin, it got %q", out.String())
+	}
+}
+
+func TestGetValidOrder(t *testing.T) {
+	tests := []struct {
+		text, order string
+	}{
+		{"leanmanager daily order random", api.OrderRandom},
+		{"<@UBOT> daily order Rotation", api.OrderRotation},
+		{"leanmanager daily order   alphabetical please", api.OrderAlphabetical},
+		{"leanmanager daily order manual <@U2> <@U1>", api.OrderManual},
+		{"leanmanager daily order randomly", ""},
+		{"leanmanager daily order", ""},
+		{"leanmanager daily order by age", ""},
+	}
+
+	for _, tt := range tests {
+		m := Message{Type: "message", Text: tt.text}
+		if got := m.getValidOrder(); got != tt.order {
+			t.Errorf("getValidOrder() of %q = %q, want %q", tt.text, got, tt.order)
+		}
+	}
+}
+
+func TestSortTeamMembers(t *testing.T) {
+	members := []api.Member{{ID: "U1", Name: "carol"}, {ID: "U2", Name: "Alice"}, {ID: "U3", Name: "bob"}}
+	ids := func(members []api.Member) []string {
+		var ids []string
+		for _, m := range members {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		daily    api.DailyMeeting
+		order    []string
+		rotation int
+	}{
+		{"storage order by default", api.DailyMeeting{}, []string{"U1", "U2", "U3"}, 0},
+		{"alphabetical ignores the case", api.DailyMeeting{Order: api.OrderAlphabetical},
+			[]string{"U2", "U3", "U1"}, 0},
+		{"rotation starts with the next member", api.DailyMeeting{Order: api.OrderRotation,
+			Rotation: 1}, []string{"U2", "U3", "U1"}, 2},
+		{"rotation wraps around", api.DailyMeeting{Order: api.OrderRotation,
+			Rotation: 5}, []string{"U3", "U1", "U2"}, 3},
+		{"manual leaves the unlisted members last", api.DailyMeeting{Order: api.OrderManual,
+			ManualOrder: []string{"U3", "U9", "U2"}}, []string{"U3", "U2", "U1"}, 0},
+	}
+
+	for _, tt := range tests {
+		d := tt.daily
+		got := ids(sortTeamMembers(members, &d))
+		if !reflect.DeepEqual(got, tt.order) || d.Rotation != tt.rotation {
+			t.Errorf("%s: sortTeamMembers() = %v with rotation %d, want %v with rotation %d",
+				tt.name, got, d.Rotation, tt.order, tt.rotation)
+		}
+	}
+	if got := ids(members); !reflect.DeepEqual(got, []string{"U1", "U2", "U3"}) {
+		t.Errorf("sortTeamMembers() changed the members to %v", got)
+	}
+
+	d := api.DailyMeeting{Order: api.OrderRandom}
+	got := ids(sortTeamMembers(members, &d))
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"U1", "U2", "U3"}) {
+		t.Errorf("random sortTeamMembers() = %v, want the same members", got)
+	}
+}
+
+func TestGetValidSnooze(t *testing.T) {
+	tests := []struct {
+		text   string
+		snooze time.Duration
+		valid  bool
+	}{
+		{"leanmanager daily snooze", defaultSnooze, true},
+		{"<@UBOT> daily snooze 30", 30 * time.Minute, true},
+		{"leanmanager daily snooze 1h30m please", 90 * time.Minute, true},
+		{"leanmanager daily snooze 4h", maxSnooze, true},
+		{"leanmanager daily snooze 5h", 0, false},
+		{"leanmanager daily snooze 0", 0, false},
+		{"leanmanager daily snooze -10m", 0, false},
+		{"leanmanager daily snooze later", 0, false},
+	}
+
+	for _, tt := range tests {
+		m := Message{Type: "message", Text: tt.text}
+		snooze, err := m.getValidSnooze()
+		if (err == nil) != tt.valid || snooze != tt.snooze {
+			t.Errorf("getValidSnooze() of %q = %v, %v, want %v, valid %t", tt.text, snooze, err, tt.snooze, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"text/template"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/i18n"
+)
+
+// defaultBotName is how the bot calls itself in the messages when the channel hasn't a persona
+const defaultBotName = "leanmanager"
+
+// render returns the message of the key for the channel: the template overridden in the API Server or the default
+// one translated to the language of the channel, executed with the data given
+func render(channelID, key string, data map[string]interface{}) string {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	name, _ := getPersona(channelID)
+	if name == "" {
+		name = defaultBotName
+	}
+	data["Bot"] = name
+
+	defaultText := i18n.T(getLanguage(channelID), api.DefaultTemplates[key])
+
+	text, err := getTemplate(channelID, key)
+	if err != nil {
+		log.Printf("slackutils: error retrieving template %s of channel %s: %v", key, channelID, err)
+	}
+	if text != "" {
+		message, err := execTemplate(key, text, data)
+		if err == nil {
+			return message
+		}
+		log.Printf("slackutils: template %s of channel %s is failing, using the default one: %v", key, channelID, err)
+	}
+
+	message, err := execTemplate(key, defaultText, data)
+	if err != nil {
+		log.Printf("slackutils: default template %s is failing: %v", key, err)
+		return defaultText
+	}
+	return message
+}
+
+func execTemplate(key, text string, data map[string]interface{}) (string, error) {
+	t, err := template.New(key).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// getPersona returns the display name and icon of the bot in the channel, empty if it hasn't its own
+func getPersona(channelID string) (name, icon string) {
+	p := channelsPrefs.get(channelID)
+	return p.botName, p.botIcon
+}
+
+func managePersonaDaily(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager daily persona Marvin :robot_face:`, "+
+			"the icon can be an emoji or the URL of an image, or `off` to be myself again"),
+	}
+
+	name, icon, ok := m.getPersonaArgs()
+	if !ok {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	_, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.BotName = name
+		d.BotIcon = icon
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! I'm myself again :relieved:")
+	if name != "" || icon != "" {
+		message.Text = tr(m.getChannelID(), "Done! Nice to meet you all :wave:")
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// getPersonaArgs reads `daily persona <name> [icon]|off`, the icon is the last word if it's an emoji or a URL.
+// `off` returns both empty, ok is false if nothing valid is typed
+func (m Message) getPersonaArgs() (name, icon string, ok bool) {
+	i := strings.Index(m.Text, "daily persona")
+	if i < 0 {
+		return "", "", false
+	}
+
+	fields := strings.Fields(m.Text[i+len("daily persona"):])
+	if len(fields) == 1 && strings.EqualFold(fields[0], "off") {
+		return "", "", true
+	}
+	if len(fields) > 0 {
+		last := strings.Trim(fields[len(fields)-1], "<>")
+		if isPersonaIcon(last) {
+			icon = last
+			fields = fields[:len(fields)-1]
+		}
+	}
+	name = strings.Join(fields, " ")
+	return name, icon, name != "" || icon != ""
+}
+
+// isPersonaIcon returns true if the text is an emoji like :robot_face: or the URL of an image
+func isPersonaIcon(text string) bool {
+	if len(text) > 2 && strings.HasPrefix(text, ":") && strings.HasSuffix(text, ":") {
+		return true
+	}
+	return strings.HasPrefix(text, "http://") || strings.HasPrefix(text, "https://")
+}
+
+func (m Message) isPersonaDailyMsj(botMention string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" daily persona") ||
+		strings.HasPrefix(m.Text, "leanmanager daily persona")) {
+		return true
+	}
+
+	return false
+}
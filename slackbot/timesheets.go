@@ -0,0 +1,442 @@
+// Package slackbot provides all the leanmanager logic for the Slack bot
+package slackbot
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antonmry/leanmanager/api"
+	"github.com/antonmry/leanmanager/scheduler"
+)
+
+// timesheetTimeout is how long the members have to fill their timesheet once asked
+const timesheetTimeout = 2 * time.Hour
+
+// timesheetLinePattern reads the lines of a timesheet, the project or PR and then the hours, e.g. `PR #42: 4.5h`
+var timesheetLinePattern = regexp.MustCompile(`^(.+?)[\s:=-]+([0-9]+(?:[.,][0-9]+)?)\s*h?$`)
+
+// launchScheduledTimesheets asks for the timesheets in the channels with a pending occurrence of their schedule
+func launchScheduledTimesheets(chat ChatAdapter) {
+	t := time.Now()
+
+	var due []api.DailyMeeting
+	channelsDailyMap.Lock()
+	for id, d := range channelsDailyMap.d {
+		if d.TimesheetSchedule == "" {
+			continue
+		}
+		s, err := scheduler.Parse(d.TimesheetSchedule)
+		if err != nil {
+			log.Printf("slackbot: invalid timesheet schedule in channel %s: %v", id, err)
+			continue
+		}
+
+		since := t.Add(-missedDailyGrace)
+		if d.LastTimesheet.After(since) {
+			since = d.LastTimesheet
+		}
+		if scheduler.Pending(s, since, t).IsZero() {
+			continue
+		}
+
+		// The occurrence is marked before asking, so the next tick doesn't ask again
+		d.LastTimesheet = t
+		channelsDailyMap.set(id, d)
+		due = append(due, d)
+	}
+	channelsDailyMap.Unlock()
+
+	for _, d := range due {
+		if err := persistDaily(d.ChannelID); err != nil {
+			log.Printf("slackbot: error invoking API Server to store the daily of channel %s: %v", d.ChannelID, err)
+		}
+		go askTimesheets(chat, d.ChannelID)
+	}
+}
+
+// askTimesheets asks every member of the channel, but the observers, for their timesheet in private
+func askTimesheets(chat ChatAdapter, channelID string) {
+	teamMembers, err := listTeamMembers(channelID)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, channelID)
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: channelID,
+		Text: tr(channelID, "Hi %s! It's time to fill the timesheets :spiral_calendar_pad: I'm asking each of "+
+			"you in private", chat.MentionChannel()),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", channelID, err)
+	}
+
+	for _, tm := range teamMembers {
+		if tm.IsObserver() {
+			continue
+		}
+		if err := askTimesheet(chat, channelID, tm.ID); err != nil {
+			log.Printf("slackutils: member %s not asked for the timesheet of channel %s: %v", tm.ID, channelID, err)
+		}
+	}
+}
+
+// askTimesheet sends the member a direct message asking the hours spent this week on each project and waits the
+// answer in background. The error tells why the member couldn't be asked: they aren't in the team, they are already
+// answering in private or the message failed
+func askTimesheet(chat ChatAdapter, channelID, memberID string) error {
+	if _, err := getTeamMember(channelID, memberID); err != nil {
+		return errNotTeamMember
+	}
+
+	// Only one conversation at a time can be awaited in private
+	channelsMap.Lock()
+	if channelsMap.p[directChannel] == nil {
+		channelsMap.p[directChannel] = map[string]chan Message{}
+	}
+	if channelsMap.p[directChannel][memberID] != nil {
+		channelsMap.Unlock()
+		return errBusyInPrivate
+	}
+	channelsMap.p[directChannel][memberID] = make(chan Message)
+	channelsMap.Unlock()
+
+	week := api.Week(time.Now())
+	if err := chat.SendDirect(memberID, tr(channelID, "Hi! Time to fill your timesheet of the week %s "+
+		":spiral_calendar_pad: How many hours did you spend on each project or PR? Type one per line with the "+
+		"hours at the end, like `backend 30` or `PR #42 4.5`", week)); err != nil {
+		channelsMap.finishWaitingMember(directChannel, memberID)
+		return err
+	}
+
+	go func() {
+		defer channelsMap.finishWaitingMember(directChannel, memberID)
+		fillTimesheet(chat, api.Timesheet{ChannelID: channelID, MemberID: memberID, Week: week})
+	}()
+	return nil
+}
+
+// fillTimesheet reads the answers of the member already asked by askTimesheet, confirming the hours if they don't
+// add up to the expected ones, and stores the timesheet
+func fillTimesheet(chat ChatAdapter, timesheet api.Timesheet) {
+	channelID, memberID := timesheet.ChannelID, timesheet.MemberID
+	expected := getExpectedHours(channelID)
+
+	for {
+		m, err := channelsMap.receiveInThread(chat, channelID, directChannel, memberID, "", timesheetTimeout, nil)
+		if err != nil {
+			if err := chat.SendDirect(memberID, tr(channelID, "Time's up! Type `@leanmanager timesheet fill` in "+
+				"the channel when you have it :hourglass:")); err != nil {
+				log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+			}
+			return
+		}
+
+		switch {
+		case m.isCancel():
+			if err := chat.SendDirect(memberID, ":ok_hand:"); err != nil {
+				log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+			}
+			return
+		case m.isYes() && len(timesheet.Entries) > 0:
+			// The hours that don't add up are kept
+		default:
+			entries, invalid := parseTimesheet(m.Text)
+			if invalid != "" {
+				if err := chat.SendDirect(memberID, tr(channelID, ":scream: I don't understand `%s`, type the "+
+					"hours at the end of each line, like `backend 30`", invalid)); err != nil {
+					log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+					return
+				}
+				continue
+			}
+			timesheet.Entries = entries
+
+			if total := timesheet.Total(); math.Abs(total-expected) > 0.01 {
+				if err := chat.SendDirect(memberID, tr(channelID, ":warning: That's %sh and I expected %sh this "+
+					"week. Type `yes` to keep it, or type all the lines again", formatHours(total),
+					formatHours(expected))); err != nil {
+					log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+					return
+				}
+				continue
+			}
+		}
+		break
+	}
+
+	if err := addTimesheet(timesheet); err != nil {
+		log.Printf("slackutils: error invoking API Server to store timesheet of %s: %v", memberID, err)
+		if err := chat.SendDirect(memberID, tr(channelID,
+			":scream: I couldn't save your timesheet, try it again later")); err != nil {
+			log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+		}
+		return
+	}
+
+	if err := chat.SendDirect(memberID, tr(channelID, "Thanks! %sh logged in the week %s :white_check_mark:",
+		formatHours(timesheet.Total()), timesheet.Week)); err != nil {
+		log.Printf("slackutils: error sending direct message to %s: %s\n", memberID, err)
+	}
+}
+
+// parseTimesheet reads one entry per line, returning the first line it doesn't understand
+func parseTimesheet(text string) (entries []api.TimesheetEntry, invalid string) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "•-* "))
+		if line == "" {
+			continue
+		}
+
+		match := timesheetLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, line
+		}
+		hours, err := strconv.ParseFloat(strings.Replace(match[2], ",", ".", 1), 64)
+		if err != nil || hours > 24*7 {
+			return nil, line
+		}
+		entries = append(entries, api.TimesheetEntry{Project: strings.TrimSpace(match[1]), Hours: hours})
+	}
+
+	if len(entries) == 0 {
+		return nil, strings.TrimSpace(text)
+	}
+	return entries, ""
+}
+
+// getExpectedHours returns the hours the timesheets of the channel should add up to every week
+func getExpectedHours(channelID string) float64 {
+	channelsDailyMap.Lock()
+	defer channelsDailyMap.Unlock()
+	if h := channelsDailyMap.d[channelID].ExpectedHours; h > 0 {
+		return h
+	}
+	return api.DefaultExpectedHours
+}
+
+func formatHours(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', -1, 64)
+}
+
+func manageScheduleTimesheet(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text: tr(m.getChannelID(), ":scream: Type something like `@leanmanager timesheet schedule friday 16:00`, "+
+			"a cron expression or `off` to stop asking"),
+	}
+
+	args := m.getTimesheetArgs("schedule")
+	expr := ""
+	if !strings.EqualFold(args, "off") {
+		var err error
+		if expr, err = scheduler.Every(args, time.Now()); err != nil || args == "" {
+			if err := message.send(chat); err != nil {
+				log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+			}
+			return
+		}
+	}
+
+	_, err := updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.TimesheetSchedule = expr
+		d.LastTimesheet = time.Now()
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! I won't ask for the timesheets anymore :+1:")
+	if expr != "" {
+		message.Text = tr(m.getChannelID(), "Done! I'll ask for the timesheets with `%s`", expr)
+		if s, err := scheduler.Parse(expr); err == nil {
+			if next := s.Next(time.Now()); !next.IsZero() {
+				message.Text += tr(m.getChannelID(), ", next time on %s", formatDayTime(m.getChannelID(), next))
+			}
+		}
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageHoursTimesheet(chat ChatAdapter, m *Message) {
+
+	if !checkAdmin(chat, m) {
+		return
+	}
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    tr(m.getChannelID(), ":scream: Type something like `@leanmanager timesheet hours 40`, the hours of a week"),
+	}
+
+	hours, err := m.getValidHours()
+	if err != nil {
+		if err := message.send(chat); err != nil {
+			log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+		}
+		return
+	}
+
+	_, err = updateDaily(m.getChannelID(), func(d *api.DailyMeeting) {
+		d.ExpectedHours = hours
+	})
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to store the daily of channel %s: %v", m.getChannelID(), err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	message.Text = tr(m.getChannelID(), "Done! The timesheets should add up to %sh every week", formatHours(hours))
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageFillTimesheet(chat ChatAdapter, m *Message) {
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+	}
+
+	switch err := askTimesheet(chat, m.getChannelID(), m.User); err {
+	case nil:
+		message.Text = tr(m.getChannelID(), "%s, I've sent you a direct message :envelope_with_arrow:",
+			chat.Mention(m.User))
+	case errNotTeamMember:
+		message.Text = tr(m.getChannelID(), "%s, only the members of the Daily Meeting fill timesheets here, type "+
+			"`@leanmanager daily add member` first", chat.Mention(m.User))
+	case errBusyInPrivate:
+		message.Text = tr(m.getChannelID(), "%s, answer first the question I've sent you in private :envelope:",
+			chat.Mention(m.User))
+	default:
+		log.Printf("slackutils: error sending direct message to %s: %s\n", m.User, err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+func manageReportTimesheet(chat ChatAdapter, m *Message) {
+	week := api.Week(time.Now())
+
+	timesheets, err := listTimesheets(m.getChannelID(), week)
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve timesheets of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+	teamMembers, err := listTeamMembers(m.getChannelID())
+	if err != nil {
+		log.Printf("slackutils: error invoking API Server to retrieve members of channel: %v", err)
+		_ = sendUnexpectedProblemMsj(chat, m.getChannelID())
+		return
+	}
+
+	totals := map[string]float64{}
+	for _, t := range timesheets {
+		totals[t.MemberID] = t.Total()
+	}
+	expected := getExpectedHours(m.getChannelID())
+
+	var b bytes.Buffer
+	b.WriteString(tr(m.getChannelID(), "Timesheets of the week %s, %sh expected :spiral_calendar_pad:", week,
+		formatHours(expected)))
+	for _, tm := range teamMembers {
+		if tm.IsObserver() {
+			continue
+		}
+		total, ok := totals[tm.ID]
+		switch {
+		case !ok:
+			b.WriteString("\n• " + chat.Mention(tm.ID) + ": " + tr(m.getChannelID(), "pending :hourglass:"))
+		case math.Abs(total-expected) > 0.01:
+			b.WriteString("\n• " + chat.Mention(tm.ID) + ": " + formatHours(total) + "h :warning:")
+		default:
+			b.WriteString("\n• " + chat.Mention(tm.ID) + ": " + formatHours(total) + "h :white_check_mark:")
+		}
+	}
+	b.WriteString("\n" + tr(m.getChannelID(), "Export them as CSV with `GET /timesheets/%s?week=%s`", m.getChannelID(),
+		week))
+
+	message := &Message{
+		ID:      0,
+		Type:    "message",
+		Channel: m.getChannelID(),
+		Text:    b.String(),
+	}
+	if err := message.send(chat); err != nil {
+		log.Printf("slackutils: error sending message to channel %s: %s\n", m.getChannelID(), err)
+	}
+}
+
+// getTimesheetArgs returns the text typed after `timesheet <command>`
+func (m Message) getTimesheetArgs(command string) string {
+	i := strings.Index(m.Text, "timesheet "+command)
+	if i < 0 {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(m.Text[i+len("timesheet "+command):]), "`")
+}
+
+// getValidHours parses the weekly hours like 40 or 37,5, at most the hours of a week
+func (m Message) getValidHours() (float64, error) {
+	hours, err := strconv.ParseFloat(strings.Replace(m.getTimesheetArgs("hours"), ",", ".", 1), 64)
+	if err != nil {
+		return 0, err
+	}
+	if hours <= 0 || hours > 24*7 {
+		return 0, fmt.Errorf("hours out of range")
+	}
+	return hours, nil
+}
+
+func (m Message) isScheduleTimesheetMsj(botMention string) bool {
+	return m.isTimesheetMsj(botMention, "schedule")
+}
+
+func (m Message) isHoursTimesheetMsj(botMention string) bool {
+	return m.isTimesheetMsj(botMention, "hours")
+}
+
+func (m Message) isFillTimesheetMsj(botMention string) bool {
+	return m.isTimesheetMsj(botMention, "fill")
+}
+
+func (m Message) isReportTimesheetMsj(botMention string) bool {
+	return m.isTimesheetMsj(botMention, "report")
+}
+
+func (m Message) isTimesheetMsj(botMention, command string) bool {
+	if m.Type == "message" && (strings.HasPrefix(m.Text, botMention+" timesheet "+command) ||
+		strings.HasPrefix(m.Text, "leanmanager timesheet "+command)) {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package slackbot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/antonmry/leanmanager/api"
+)
+
+func TestParseTimesheet(t *testing.T) {
+	tests := []struct {
+		text    string
+		entries []api.TimesheetEntry
+		invalid string
+	}{
+		{"PR #42: 4.5h", []api.TimesheetEntry{{Project: "PR #42", Hours: 4.5}}, ""},
+		{"backend 40", []api.TimesheetEntry{{Project: "backend", Hours: 40}}, ""},
+		{"• frontend - 3,5 h\n\n* support = 2\n- meetings: 1h",
+			[]api.TimesheetEntry{{Project: "frontend", Hours: 3.5}, {Project: "support", Hours: 2},
+				{Project: "meetings", Hours: 1}}, ""},
+		{"sprint 12 review 2h", []api.TimesheetEntry{{Project: "sprint 12 review", Hours: 2}}, ""},
+		{"backend 40\nlots of things", nil, "lots of things"},
+		{"backend 169", nil, "backend 169"},
+		{"backend -", nil, "backend -"},
+		{"  ", nil, ""},
+	}
+
+	for _, tt := range tests {
+		entries, invalid := parseTimesheet(tt.text)
+		if !reflect.DeepEqual(entries, tt.entries) || invalid != tt.invalid {
+			t.Errorf("parseTimesheet(%q) = %+v, %q, want %+v, %q", tt.text, entries, invalid, tt.entries, tt.invalid)
+		}
+	}
+}
+
+func TestTimesheetTotal(t *testing.T) {
+	tests := []struct {
+		entries []api.TimesheetEntry
+		total   float64
+	}{
+		{nil, 0},
+		{[]api.TimesheetEntry{{Project: "backend", Hours: 40}}, 40},
+		{[]api.TimesheetEntry{{Project: "frontend", Hours: 3.5}, {Project: "support", Hours: 2.25},
+			{Project: "frontend", Hours: 1}}, 6.75},
+	}
+
+	for _, tt := range tests {
+		if got := (api.Timesheet{Entries: tt.entries}).Total(); got != tt.total {
+			t.Errorf("Total() of %+v = %v, want %v", tt.entries, got, tt.total)
+		}
+	}
+}
+
+func TestGetValidHours(t *testing.T) {
+	tests := []struct {
+		text  string
+		hours float64
+		valid bool
+	}{
+		{"leanmanager timesheet hours 40", 40, true},
+		{"<@UBOT> timesheet hours `37,5`", 37.5, true},
+		{"leanmanager timesheet hours 168", 168, true},
+		{"leanmanager timesheet hours 0", 0, false},
+		{"leanmanager timesheet hours 169", 0, false},
+		{"leanmanager timesheet hours -8", 0, false},
+		{"leanmanager timesheet hours forty", 0, false},
+		{"leanmanager timesheet hours", 0, false},
+	}
+
+	for _, tt := range tests {
+		m := Message{Type: "message", Text: tt.text}
+		hours, err := m.getValidHours()
+		if (err == nil) != tt.valid || hours != tt.hours {
+			t.Errorf("getValidHours() of %q = %v, %v, want %v, valid %t", tt.text, hours, err, tt.hours, tt.valid)
+		}
+	}
+}
+
+func TestGetTimesheetArgs(t *testing.T) {
+	tests := []struct {
+		text, command, args string
+	}{
+		{"leanmanager timesheet schedule every friday 16:00", "schedule", "every friday 16:00"},
+		{"<@UBOT> timesheet report `2017-W12`", "report", "2017-W12"},
+		{"leanmanager timesheet report", "report", ""},
+		{"leanmanager timesheet fill", "hours", ""},
+	}
+
+	for _, tt := range tests {
+		m := Message{Type: "message", Text: tt.text}
+		if got := m.getTimesheetArgs(tt.command); got != tt.args {
+			t.Errorf("getTimesheetArgs(%q) of %q = %q, want %q", tt.command, tt.text, got, tt.args)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"time"
 
 	"github.com/antonmry/leanmanager/api"
 	"github.com/boltdb/bolt"
@@ -19,6 +20,18 @@ func (f NotMemberFoundError) Error() string {
 	return fmt.Sprintf("Not member found with username %s", string(f))
 }
 
+// buckets are created by InitDB, channels can't be registered with their names because their data is stored in a
+// bucket named as the channel
+var buckets = []string{"dailymeetings", "predefinedreplies", "channels", "apikeys", "meetings", "retros",
+	"actionitems", "moods", "progress", "digests", "reminders", "timesheets", "absences", "templates"}
+
+// ReservedChannelError is returned when the ID of a channel is the name of one of the buckets of the database
+type ReservedChannelError string
+
+func (f ReservedChannelError) Error() string {
+	return fmt.Sprintf("Channel ID %s is reserved", string(f))
+}
+
 // InitDB initializes the database, creating or opening the file
 func InitDB(path string) error {
 	var err error
@@ -27,22 +40,37 @@ func InitDB(path string) error {
 		return err
 	}
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists([]byte("dailymeetings")); err != nil {
-			return fmt.Errorf("dbutils: create bucket: %s", err)
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return fmt.Errorf("dbutils: create bucket: %s", err)
+			}
 		}
 		return nil
 	})
-	if err != nil {
-		return err
-	}
+}
 
-	return db.Update(func(tx *bolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists([]byte("predefinedreplies")); err != nil {
-			return fmt.Errorf("dbutils: create bucket: %s", err)
+// isReserved checks if the name is one of the buckets created by InitDB
+func isReserved(name string) bool {
+	for _, b := range buckets {
+		if name == b {
+			return true
 		}
-		return nil
-	})
+	}
+	return false
+}
+
+// channelBucket returns the bucket with the data of a channel, never one of the buckets of the database
+func channelBucket(tx *bolt.Tx, channelID string) (*bolt.Bucket, error) {
+	if isReserved(channelID) {
+		return nil, ReservedChannelError(channelID)
+	}
+
+	b := tx.Bucket([]byte(channelID))
+	if b == nil {
+		return nil, fmt.Errorf("dbutils: bucket %s not created", channelID)
+	}
+	return b, nil
 }
 
 // CloseDB terminate the DB Session in a properly way
@@ -50,23 +78,136 @@ func CloseDB() error {
 	return db.Close()
 }
 
-// StoreChannel create a bucket by channel where data can be stored
+// StoreChannel create a bucket by channel where data can be stored, and saves the team it belongs to
 func StoreChannel(channelToBeCreated api.Channel) error {
+	if isReserved(channelToBeCreated.ID) {
+		return ReservedChannelError(channelToBeCreated.ID)
+	}
+
 	return db.Update(func(tx *bolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists([]byte(channelToBeCreated.ID))
 		if err != nil {
 			return fmt.Errorf("dbutils: create bucket: %s", err)
 		}
+
+		b := tx.Bucket([]byte("channels"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket channels not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(channelToBeCreated)
+
+		return b.Put([]byte(channelToBeCreated.ID), buf.Bytes())
+	})
+}
+
+// GetChannel returns the channel, registered by StoreChannel
+func GetChannel(channelID string) (channel *api.Channel, err error) {
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("channels"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket channels not created")
+		}
+
+		v := b.Get([]byte(channelID))
+		if v == nil {
+			return fmt.Errorf("dbutils: channel %s not found", channelID)
+		}
+
+		buf := *bytes.NewBuffer(v)
+		dec := gob.NewDecoder(&buf)
+		return dec.Decode(&channel)
+	})
+
+	return
+}
+
+// StoreAPIKey persists an API key, identified by the key itself
+func StoreAPIKey(key api.APIKey) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("apikeys"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket apikeys not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(key)
+
+		return b.Put([]byte(key.Key), buf.Bytes())
+	})
+}
+
+// GetAPIKey returns the API key with its team and permission
+func GetAPIKey(key string) (apiKey *api.APIKey, err error) {
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("apikeys"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket apikeys not created")
+		}
+
+		v := b.Get([]byte(key))
+		if v == nil {
+			return fmt.Errorf("dbutils: API key not found")
+		}
+
+		buf := *bytes.NewBuffer(v)
+		dec := gob.NewDecoder(&buf)
+		return dec.Decode(&apiKey)
+	})
+
+	return
+}
+
+// GetAPIKeysByTeam returns all the API keys of a team
+func GetAPIKeysByTeam(teamID string, keys *[]api.APIKey) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("apikeys"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket apikeys not created")
+		}
+
+		c := b.Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+
+			var key api.APIKey
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&key)
+			if key.TeamID == teamID {
+				*keys = append(*keys, key)
+			}
+		}
+
 		return nil
 	})
 }
 
+// DeleteAPIKey revokes an API key
+func DeleteAPIKey(key string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("apikeys"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket apikeys not created")
+		}
+
+		if v := b.Get([]byte(key)); v == nil {
+			return fmt.Errorf("dbutils: API key not found")
+		}
+
+		return b.Delete([]byte(key))
+	})
+}
+
 // StoreMember persists a member inside a bucket identifying the channel
 func StoreMember(member api.Member) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(member.ChannelID))
-		if b == nil {
-			return fmt.Errorf("dbutils: bucket %s not created", member.ChannelID)
+		b, err := channelBucket(tx, member.ChannelID)
+		if err != nil {
+			return err
 		}
 
 		var buf bytes.Buffer
@@ -81,9 +222,9 @@ func StoreMember(member api.Member) error {
 // DeleteMember deletes a member from a bucket which identifies the channel
 func DeleteMember(channelID, memberID string) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(channelID))
-		if b == nil {
-			return fmt.Errorf("dbutils: bucket %s not created", channelID)
+		b, err := channelBucket(tx, channelID)
+		if err != nil {
+			return err
 		}
 
 		if v := b.Get([]byte(memberID)); v == nil {
@@ -97,9 +238,9 @@ func DeleteMember(channelID, memberID string) error {
 // GetMemberByName returns member which is identified by a string, the name
 func GetMemberByName(channelID, memberName string) (member *api.Member, err error) {
 	err = db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(channelID))
-		if b == nil {
-			return fmt.Errorf("dbutils: bucket %s not created", channelID)
+		b, err := channelBucket(tx, channelID)
+		if err != nil {
+			return err
 		}
 		v := b.Get([]byte(memberName))
 		if v == nil {
@@ -119,17 +260,16 @@ func GetMemberByName(channelID, memberName string) (member *api.Member, err erro
 func GetMembersByChannel(channelID string, teamMembers *[]api.Member) error {
 
 	err := db.View(func(tx *bolt.Tx) error {
-		// Assume bucket exists and has keys
-		b := tx.Bucket([]byte(channelID))
-		if b == nil {
-			return fmt.Errorf("dbutils: bucket %s not created", channelID)
+		b, err := channelBucket(tx, channelID)
+		if err != nil {
+			return err
 		}
 
 		c := b.Cursor()
-		var member api.Member
 
 		for k, v := c.First(); k != nil; k, v = c.Next() {
 
+			var member api.Member
 			buf := *bytes.NewBuffer(v)
 			dec := gob.NewDecoder(&buf)
 			dec.Decode(&member)
@@ -172,10 +312,10 @@ func GetDailyMeetingsByBot(botID string, teamDailyMeetings *[]api.DailyMeeting)
 		}
 
 		d := b.Cursor()
-		var daily api.DailyMeeting
 
 		for k, v := d.First(); k != nil; k, v = d.Next() {
 
+			var daily api.DailyMeeting
 			buf := *bytes.NewBuffer(v)
 			dec := gob.NewDecoder(&buf)
 			dec.Decode(&daily)
@@ -188,6 +328,569 @@ func GetDailyMeetingsByBot(botID string, teamDailyMeetings *[]api.DailyMeeting)
 	return err
 }
 
+// GetDailyMeeting returns the daily meeting configuration of a channel
+func GetDailyMeeting(channelID string, daily *api.DailyMeeting) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("dailymeetings"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket dailymeetings not created")
+		}
+
+		v := b.Get([]byte(channelID))
+		if v == nil {
+			return fmt.Errorf("dbutils: daily meeting of channel %s not found", channelID)
+		}
+
+		buf := *bytes.NewBuffer(v)
+		dec := gob.NewDecoder(&buf)
+		return dec.Decode(daily)
+	})
+}
+
+// StoreDailyProgress persists the state of the Daily Meeting in progress of a channel
+func StoreDailyProgress(progress api.DailyProgress) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("progress"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket progress not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(progress)
+
+		return b.Put([]byte(progress.ChannelID), buf.Bytes())
+	})
+}
+
+// GetDailyProgresses returns the Daily Meetings in progress of all the channels
+func GetDailyProgresses(progresses *[]api.DailyProgress) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("progress"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket progress not created")
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var progress api.DailyProgress
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&progress)
+			*progresses = append(*progresses, progress)
+			return nil
+		})
+	})
+}
+
+// DeleteDailyProgress removes the Daily Meeting in progress of the channel, once it's over
+func DeleteDailyProgress(channelID string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("progress"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket progress not created")
+		}
+
+		if v := b.Get([]byte(channelID)); v == nil {
+			return fmt.Errorf("dbutils: no Daily Meeting in progress in channel %s", channelID)
+		}
+
+		return b.Delete([]byte(channelID))
+	})
+}
+
+// StoreDailyDigest persists the digest of the Daily Meeting of a channel in a day, replacing the previous one
+func StoreDailyDigest(digest api.DailyDigest) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("digests"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket digests not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(digest)
+
+		return b.Put([]byte(digest.ChannelID+"/"+digest.Date.Format("2006-01-02")), buf.Bytes())
+	})
+}
+
+// GetDailyDigest returns the digest of the Daily Meeting of a channel in the day given
+func GetDailyDigest(channelID string, date time.Time, digest *api.DailyDigest) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("digests"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket digests not created")
+		}
+
+		v := b.Get([]byte(channelID + "/" + date.Format("2006-01-02")))
+		if v == nil {
+			return fmt.Errorf("dbutils: no Daily Meeting in channel %s on %s", channelID, date.Format("2006-01-02"))
+		}
+
+		buf := *bytes.NewBuffer(v)
+		dec := gob.NewDecoder(&buf)
+		return dec.Decode(digest)
+	})
+}
+
+// AddDigestAnswers appends the answers to the digest of the Daily Meeting of a channel in the day given, in the
+// same transaction so answers given at the same time aren't lost
+func AddDigestAnswers(channelID string, date time.Time, answers []api.DailyAnswer) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("digests"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket digests not created")
+		}
+
+		key := []byte(channelID + "/" + date.Format("2006-01-02"))
+		v := b.Get(key)
+		if v == nil {
+			return fmt.Errorf("dbutils: no Daily Meeting in channel %s on %s", channelID, date.Format("2006-01-02"))
+		}
+
+		var digest api.DailyDigest
+		dec := gob.NewDecoder(bytes.NewBuffer(v))
+		if err := dec.Decode(&digest); err != nil {
+			return err
+		}
+		digest.Answers = append(digest.Answers, answers...)
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(digest)
+
+		return b.Put(key, buf.Bytes())
+	})
+}
+
+// StoreMeeting persists a meeting, identified by its channel and ID
+func StoreMeeting(meeting api.Meeting) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("meetings"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket meetings not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(meeting)
+
+		return b.Put([]byte(meeting.ChannelID+"/"+meeting.ID), buf.Bytes())
+	})
+}
+
+// GetMeetings returns the meetings of the channel, or all of them if channelID is empty
+func GetMeetings(channelID string, meetings *[]api.Meeting) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("meetings"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket meetings not created")
+		}
+
+		prefix := []byte(channelID)
+		if channelID != "" {
+			prefix = []byte(channelID + "/")
+		}
+
+		c := b.Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var meeting api.Meeting
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&meeting)
+			*meetings = append(*meetings, meeting)
+		}
+
+		return nil
+	})
+}
+
+// DeleteMeeting removes a meeting of the channel
+func DeleteMeeting(channelID, meetingID string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("meetings"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket meetings not created")
+		}
+
+		key := []byte(channelID + "/" + meetingID)
+		if v := b.Get(key); v == nil {
+			return fmt.Errorf("dbutils: meeting %s not found in channel %s", meetingID, channelID)
+		}
+
+		return b.Delete(key)
+	})
+}
+
+// StoreRetroEntry persists an entry of a Retrospective, numbering it if it's new
+func StoreRetroEntry(entry *api.RetroEntry) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("retros"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket retros not created")
+		}
+
+		if entry.ID == 0 {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			entry.ID = int(id)
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(entry)
+
+		return b.Put([]byte(fmt.Sprintf("%s/%08d", entry.ChannelID, entry.ID)), buf.Bytes())
+	})
+}
+
+// GetRetroEntries returns the entries of all the Retrospectives of the channel
+func GetRetroEntries(channelID string, entries *[]api.RetroEntry) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("retros"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket retros not created")
+		}
+
+		prefix := []byte(channelID + "/")
+		c := b.Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var entry api.RetroEntry
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&entry)
+			*entries = append(*entries, entry)
+		}
+
+		return nil
+	})
+}
+
+// StoreActionItem persists an action item, numbering it if it's new
+func StoreActionItem(item *api.ActionItem) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("actionitems"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket actionitems not created")
+		}
+
+		if item.ID == 0 {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			item.ID = int(id)
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(item)
+
+		return b.Put([]byte(fmt.Sprintf("%s/%08d", item.ChannelID, item.ID)), buf.Bytes())
+	})
+}
+
+// GetActionItems returns the action items of the channel, the done ones included
+func GetActionItems(channelID string, items *[]api.ActionItem) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("actionitems"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket actionitems not created")
+		}
+
+		prefix := []byte(channelID + "/")
+		c := b.Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var item api.ActionItem
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&item)
+			*items = append(*items, item)
+		}
+
+		return nil
+	})
+}
+
+// StoreReminder persists a reminder, numbering it if it's new
+func StoreReminder(reminder *api.Reminder) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("reminders"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket reminders not created")
+		}
+
+		if reminder.ID == 0 {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			reminder.ID = int(id)
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(reminder)
+
+		return b.Put([]byte(fmt.Sprintf("%s/%08d", reminder.ChannelID, reminder.ID)), buf.Bytes())
+	})
+}
+
+// GetReminders returns the reminders created in the channel, or all of them if channelID is empty
+func GetReminders(channelID string, reminders *[]api.Reminder) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("reminders"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket reminders not created")
+		}
+
+		prefix := []byte(channelID)
+		if channelID != "" {
+			prefix = []byte(channelID + "/")
+		}
+
+		c := b.Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var reminder api.Reminder
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&reminder)
+			*reminders = append(*reminders, reminder)
+		}
+
+		return nil
+	})
+}
+
+// DeleteReminder removes a reminder of the channel
+func DeleteReminder(channelID string, reminderID int) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("reminders"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket reminders not created")
+		}
+
+		key := []byte(fmt.Sprintf("%s/%08d", channelID, reminderID))
+		if v := b.Get(key); v == nil {
+			return fmt.Errorf("dbutils: reminder %d not found in channel %s", reminderID, channelID)
+		}
+
+		return b.Delete(key)
+	})
+}
+
+// StoreTimesheet persists the timesheet of a member in a week, replacing the previous one
+func StoreTimesheet(timesheet api.Timesheet) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("timesheets"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket timesheets not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(timesheet)
+
+		return b.Put([]byte(timesheet.ChannelID+"/"+timesheet.Week+"/"+timesheet.MemberID), buf.Bytes())
+	})
+}
+
+// GetTimesheets returns the timesheets of the members of the channel in the week
+func GetTimesheets(channelID, week string, timesheets *[]api.Timesheet) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("timesheets"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket timesheets not created")
+		}
+
+		prefix := []byte(channelID + "/" + week + "/")
+		c := b.Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var timesheet api.Timesheet
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&timesheet)
+			*timesheets = append(*timesheets, timesheet)
+		}
+
+		return nil
+	})
+}
+
+// StoreTemplate persists the template of a message overridden in the channel
+func StoreTemplate(t *api.MessageTemplate) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("templates"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket templates not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(t)
+
+		return b.Put([]byte(t.ChannelID+"/"+t.Key), buf.Bytes())
+	})
+}
+
+// GetTemplates returns the templates overridden in the channel
+func GetTemplates(channelID string, templates *[]api.MessageTemplate) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("templates"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket templates not created")
+		}
+
+		prefix := []byte(channelID + "/")
+		c := b.Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var t api.MessageTemplate
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&t)
+			*templates = append(*templates, t)
+		}
+
+		return nil
+	})
+}
+
+// DeleteTemplate removes the template overridden in the channel, the default one is used again
+func DeleteTemplate(channelID, key string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("templates"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket templates not created")
+		}
+
+		k := []byte(channelID + "/" + key)
+		if v := b.Get(k); v == nil {
+			return fmt.Errorf("dbutils: template %s not found in channel %s", key, channelID)
+		}
+
+		return b.Delete(k)
+	})
+}
+
+// StoreAbsence persists an absence, numbering it if it's new
+func StoreAbsence(absence *api.Absence) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("absences"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket absences not created")
+		}
+
+		if absence.ID == 0 {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			absence.ID = int(id)
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(absence)
+
+		return b.Put([]byte(fmt.Sprintf("%s/%08d", absence.ChannelID, absence.ID)), buf.Bytes())
+	})
+}
+
+// GetAbsences returns the absences of the members of the channel, the past ones included
+func GetAbsences(channelID string, absences *[]api.Absence) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("absences"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket absences not created")
+		}
+
+		prefix := []byte(channelID + "/")
+		c := b.Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var absence api.Absence
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&absence)
+			*absences = append(*absences, absence)
+		}
+
+		return nil
+	})
+}
+
+// DeleteAbsence removes an absence of the channel
+func DeleteAbsence(channelID string, absenceID int) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("absences"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket absences not created")
+		}
+
+		key := []byte(fmt.Sprintf("%s/%08d", channelID, absenceID))
+		if v := b.Get(key); v == nil {
+			return fmt.Errorf("dbutils: absence %d not found in channel %s", absenceID, channelID)
+		}
+
+		return b.Delete(key)
+	})
+}
+
+// StoreMood persists the mood of a member, only the last one of each day is kept
+func StoreMood(mood api.Mood) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("moods"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket moods not created")
+		}
+
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		enc.Encode(mood)
+
+		return b.Put([]byte(mood.ChannelID+"/"+mood.Date.Format("2006-01-02")+"/"+mood.MemberID), buf.Bytes())
+	})
+}
+
+// GetMoods returns the moods of the members of the channel since the day given, sorted by day
+func GetMoods(channelID string, since time.Time, moods *[]api.Mood) error {
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("moods"))
+		if b == nil {
+			return fmt.Errorf("dbutils: bucket moods not created")
+		}
+
+		prefix := []byte(channelID + "/")
+		c := b.Cursor()
+
+		for k, v := c.Seek([]byte(channelID + "/" + since.Format("2006-01-02"))); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+
+			var mood api.Mood
+			buf := *bytes.NewBuffer(v)
+			dec := gob.NewDecoder(&buf)
+			dec.Decode(&mood)
+			*moods = append(*moods, mood)
+		}
+
+		return nil
+	})
+}
+
 // StorePredefinedReply saves a predefined reply used to reply to Daily Meeting answers
 func StorePredefinedReply(reply api.PredefinedDailyReply) error {
 	// TODO: persist by TeamID or BotID